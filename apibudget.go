@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/cornelk/gotokit/log"
+	"gopkg.in/yaml.v3"
+)
+
+// apiBudgetStateFile is written when the API call budget is exhausted.
+const apiBudgetStateFile = "gitlab2gitea-state.yaml"
+
+var errAPIBudgetExhausted = errors.New("API call budget exhausted")
+
+// apiCallCounter counts the requests to the GitLab and Gitea APIs. Once the
+// optional budget is exhausted, all following requests fail.
+type apiCallCounter struct {
+	next   http.RoundTripper
+	budget int64
+	calls  atomic.Int64
+}
+
+func (c *apiCallCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if calls := c.calls.Add(1); c.budget > 0 && calls > c.budget {
+		c.calls.Add(-1)
+		return nil, errAPIBudgetExhausted
+	}
+	return c.next.RoundTrip(req)
+}
+
+// migrationState describes where a migration stopped, to resume it later.
+type migrationState struct {
+	Phase         string `yaml:"phase"`
+	Item          string `yaml:"item,omitempty"`
+	ResumeFromIID int    `yaml:"resume_from_iid,omitempty"`
+	APICalls      int64  `yaml:"api_calls"`
+}
+
+// writeBudgetState writes the state of the migration that was stopped by the
+// exhausted API call budget. Items that were already migrated are matched on
+// the next run, the resume IID is only set if issues are listed in ascending
+// IID order.
+func (m *migrator) writeBudgetState(migrationErr *MigrationError) error {
+	state := migrationState{
+		Phase:    migrationErr.Phase(),
+		APICalls: m.apiCalls.calls.Load(),
+	}
+	if failures := migrationErr.Failures(); len(failures) > 0 {
+		state.Item = failures[len(failures)-1].Item
+	}
+	if state.Phase == phaseMigratingIssues && m.args.ResumeFromIID > 0 && m.args.IssueOrder == issueOrderCreated {
+		state.ResumeFromIID = m.currentIssueIID
+	}
+
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encoding migration state: %w", err)
+	}
+	if err := os.WriteFile(apiBudgetStateFile, data, 0o600); err != nil {
+		return fmt.Errorf("writing migration state: %w", err)
+	}
+
+	m.logger.Warn("API call budget exhausted, migration stopped",
+		log.Int64("budget", m.args.MaxAPICalls),
+		log.String("phase", state.Phase),
+		log.String("item", state.Item),
+		log.String("state_file", apiBudgetStateFile),
+	)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// reportCapabilities prints the data of the GitLab project that will not be
+// migrated. Nothing is written to Gitea.
+func (m *migrator) reportCapabilities() error {
+	closed := "closed"
+	one := gitlab.ListOptions{PerPage: 1}
+
+	counters := []struct {
+		name  string
+		count func() ([]any, *gitlab.Response, error)
+	}{
+		{"wiki pages", func() ([]any, *gitlab.Response, error) {
+			pages, resp, err := m.gitlab.Wikis.ListWikis(m.gitlabProjectID, &gitlab.ListWikisOptions{}, nil)
+			return toAny(pages), resp, err
+		}},
+		{"merge requests", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListProjectMergeRequestsOptions{ListOptions: one}
+			mrs, resp, err := m.gitlab.MergeRequests.ListProjectMergeRequests(m.gitlabProjectID, opt, nil)
+			return toAny(mrs), resp, err
+		}},
+		{"releases", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListReleasesOptions{ListOptions: one}
+			releases, resp, err := m.gitlab.Releases.ListReleases(m.gitlabProjectID, opt, nil)
+			return toAny(releases), resp, err
+		}},
+		{"packages", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListProjectPackagesOptions{ListOptions: one}
+			packages, resp, err := m.gitlab.Packages.ListProjectPackages(m.gitlabProjectID, opt, nil)
+			return toAny(packages), resp, err
+		}},
+		{"CI pipelines", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListProjectPipelinesOptions{ListOptions: one}
+			pipelines, resp, err := m.gitlab.Pipelines.ListProjectPipelines(m.gitlabProjectID, opt, nil)
+			return toAny(pipelines), resp, err
+		}},
+		{"snippets", func() ([]any, *gitlab.Response, error) {
+			opt := gitlab.ListProjectSnippetsOptions(one)
+			snippets, resp, err := m.gitlab.ProjectSnippets.ListSnippets(m.gitlabProjectID, &opt, nil)
+			return toAny(snippets), resp, err
+		}},
+		{"closed issues", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListProjectIssuesOptions{ListOptions: one, State: &closed}
+			issues, resp, err := m.gitlab.Issues.ListProjectIssues(m.gitlabProjectID, opt, nil)
+			return toAny(issues), resp, err
+		}},
+		{"closed milestones", func() ([]any, *gitlab.Response, error) {
+			opt := &gitlab.ListMilestonesOptions{ListOptions: one, State: &closed}
+			milestones, resp, err := m.gitlab.Milestones.ListMilestones(m.gitlabProjectID, opt, nil)
+			return toAny(milestones), resp, err
+		}},
+	}
+
+	var unsupported []string
+	for _, counter := range counters {
+		items, resp, err := counter.count()
+		if err != nil {
+			if apiUnavailable(err) {
+				continue
+			}
+			return fmt.Errorf("counting %s: %w", counter.name, err)
+		}
+		if count := itemCount(items, resp); count != "" {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", counter.name, count))
+		}
+	}
+
+	if len(unsupported) == 0 {
+		fmt.Println("All data of the GitLab project is supported by the migration.")
+		return nil
+	}
+
+	fmt.Printf("The following will NOT be migrated: %s\n", strings.Join(unsupported, ", "))
+	return nil
+}
+
+// itemCount returns the total number of items of a listing as text, using the
+// total header of paginated responses. GitLab omits the header for large
+// listings, in that case only a lower bound is returned. An empty string is
+// returned if the listing is empty.
+func itemCount(items []any, resp *gitlab.Response) string {
+	switch {
+	case len(items) == 0:
+		return ""
+	case resp != nil && resp.TotalItems > 0:
+		return fmt.Sprint(resp.TotalItems)
+	case resp != nil && resp.NextPage > 0:
+		return fmt.Sprintf("more than %d", len(items))
+	default:
+		return fmt.Sprint(len(items))
+	}
+}
+
+// toAny converts a slice to a slice of any.
+func toAny[T any](items []T) []any {
+	result := make([]any, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// migrateCommentsCmd is the subcommand that migrates the comments of already
+// migrated issues.
+type migrateCommentsCmd struct {
+	MapFile string `arg:"positional,required" help:"CSV issue mapping file written by --mapcsv"`
+}
+
+var noteMarkerRegex = regexp.MustCompile(`<!-- gitlab2gitea:note:(\d+) -->`)
+
+// noteMarker returns the hidden marker that is embedded in the Gitea comment
+// body to identify the GitLab note that it was migrated from.
+func noteMarker(noteID int) string {
+	return fmt.Sprintf("<!-- gitlab2gitea:note:%d -->", noteID)
+}
+
+// migrateComments migrates the non system notes of all GitLab issues in the
+// issue mapping file as comments of their Gitea issues. Notes that were
+// already migrated are found by their marker and skipped.
+func (m *migrator) migrateComments() error {
+	mappings, err := readIssueMapping(m.args.MigrateComments.MapFile)
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range mappings {
+		if mapping.gitlabURL == "" {
+			// placeholders of deleted issues were mapped by older versions
+			m.logger.Debug("Skipping comments of issue without GitLab URL", log.Int("iid", mapping.gitlabIID))
+			continue
+		}
+
+		err := m.migrateItem(fmt.Sprintf("Comments of issue %d", mapping.gitlabIID), func() error {
+			return m.migrateIssueComments(mapping.gitlabIID, mapping.giteaIndex)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateIssueComments migrates the notes of a GitLab issue as comments of the
+// Gitea issue with the given index.
+func (m *migrator) migrateIssueComments(iid int, index int64) error {
+	notes, err := m.gitlabIssueNotes(iid)
+	if err != nil {
+		return err
+	}
+	migrated, err := m.migratedNotes(index)
+	if err != nil {
+		return err
+	}
+
+	var created int
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		if _, ok := migrated[note.ID]; ok {
+			continue
+		}
+
+		o := gitea.CreateIssueCommentOption{
+			Body: noteCommentBody(note),
+		}
+		if _, _, err := m.gitea.CreateIssueComment(m.giteaOwner, m.giteaRepo, index, o); err != nil {
+			return fmt.Errorf("creating comment for note %d: %w", note.ID, err)
+		}
+		created++
+	}
+
+	m.logger.Info("Migrated comments",
+		log.Int("iid", iid),
+		log.Int64("index", index),
+		log.Int("created", created),
+	)
+	return nil
+}
+
+// migratedNotes returns the IDs of the GitLab notes that were already
+// migrated as comments of the Gitea issue with the given index.
+func (m *migrator) migratedNotes(index int64) (map[int]struct{}, error) {
+	comments, err := m.giteaIssueComments(index)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := map[int]struct{}{}
+	for _, comment := range comments {
+		if match := noteMarkerRegex.FindStringSubmatch(comment.Body); match != nil {
+			id, _ := strconv.Atoi(match[1])
+			migrated[id] = struct{}{}
+		}
+	}
+	return migrated, nil
+}
+
+// giteaIssueComments returns all comments of the Gitea issue with the given
+// index. Gitea returns all comments of an issue at once and ignores paging
+// parameters, so the comments are requested only once.
+func (m *migrator) giteaIssueComments(index int64) ([]*gitea.Comment, error) {
+	comments, _, err := m.gitea.ListIssueComments(m.giteaOwner, m.giteaRepo, index, gitea.ListIssueCommentOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing comments of issue %d: %w", index, err)
+	}
+	return comments, nil
+}
+
+// noteCommentBody returns the Gitea comment body of a GitLab note, prefixed
+// with its author and date.
+func noteCommentBody(note *gitlab.Note) string {
+	var date string
+	if note.CreatedAt != nil {
+		date = " on " + note.CreatedAt.UTC().Format(time.DateTime)
+	}
+	return fmt.Sprintf("**%s**%s:\n\n%s\n\n%s", note.Author.Username, date, note.Body, noteMarker(note.ID))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigratedNotesUnpaged(t *testing.T) {
+	var requests int
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		// Gitea ignores the paging parameters and returns all comments
+		"/api/v1/repos/owner/repo/issues/4/comments": func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			writeJSON(t, w, []*gitea.Comment{
+				{ID: 1, Body: "text\n\n" + noteMarker(11)},
+				{ID: 2, Body: "added in Gitea"},
+				{ID: 3, Body: "text\n\n" + noteMarker(13)},
+			})
+		},
+	})
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+	m := newTestMigrator(t, arguments{})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+
+	migrated, err := m.migratedNotes(4)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]struct{}{11: {}, 13: {}}, migrated)
+	assert.Equal(t, 1, requests)
+}
+
+func TestMigrateCommentsSkipsPlaceholders(t *testing.T) {
+	mapFile := filepath.Join(t.TempDir(), "issues.csv")
+	data := "gitlab_iid,gitlab_url,gitea_index,gitea_url\n3,,3,https://gitea.example.com/owner/repo/issues/3\n"
+	require.NoError(t, os.WriteFile(mapFile, []byte(data), 0o600))
+
+	// without clients, any request for the deleted issue panics
+	m := newTestMigrator(t, arguments{
+		MigrateComments: &migrateCommentsCmd{MapFile: mapFile},
+	})
+	assert.NoError(t, m.migrateComments())
+}
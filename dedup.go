@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"code.gitea.io/sdk/gitea"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Supported strategies to match GitLab issues with existing Gitea issues.
+const (
+	dedupMarker     = "marker"
+	dedupTitle      = "title"
+	dedupExternalID = "externalid"
+)
+
+// Supported styles of the issue marker.
+const (
+	markerStyleHTML = "html"
+	markerStyleText = "text"
+)
+
+// defaultMarkerFormat is the default template of the issue marker.
+const defaultMarkerFormat = "gitlab2gitea:{project}#{iid}"
+
+var externalIDRegex = regexp.MustCompile(`(?m)^External-ID:\s*(\S+)\s*$`)
+
+// formatMarker returns the issue marker for the given project and IID. The
+// html style hides the marker in a comment, the text style uses it as is.
+func formatMarker(format, style, project string, iid int) string {
+	marker := strings.NewReplacer("{project}", project, "{iid}", strconv.Itoa(iid)).Replace(format)
+	if style == markerStyleText {
+		return marker
+	}
+	return "<!-- " + marker + " -->"
+}
+
+// compileMarkerRegex returns the regex that parses issue markers of the given
+// format and style. The project and IID are captured as named groups.
+func compileMarkerRegex(format, style string) (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(format)
+	expr = strings.Replace(expr, regexp.QuoteMeta("{project}"), `(?P<project>\S+?)`, 1)
+	expr = strings.Replace(expr, regexp.QuoteMeta("{iid}"), `(?P<iid>\d+)`, 1)
+	if style == markerStyleText {
+		expr = `(?m)^` + expr + `$`
+	} else {
+		expr = `<!-- ` + expr + ` -->`
+	}
+	return regexp.Compile(expr)
+}
+
+// validateMarkerFormat checks that markers of the given format and style can
+// be parsed again to the project and IID that they were created for.
+func validateMarkerFormat(format, style string) error {
+	if !strings.Contains(format, "{iid}") {
+		return fmt.Errorf("marker format '%s' does not contain {iid}", format)
+	}
+	if strings.Count(format, "{iid}") > 1 || strings.Count(format, "{project}") > 1 {
+		return fmt.Errorf("marker format '%s' contains a placeholder more than once", format)
+	}
+	if strings.ContainsAny(format, "\n\r") {
+		return fmt.Errorf("marker format '%s' contains a line break", format)
+	}
+	// text markers take a whole line, without literal text any line with a
+	// number or reference would be taken as marker
+	literal := strings.NewReplacer("{project}", "", "{iid}", "").Replace(format)
+	if style == markerStyleText && !strings.ContainsFunc(literal, unicode.IsLetter) {
+		return fmt.Errorf("marker format '%s' needs literal text besides the placeholders", format)
+	}
+
+	markerRegex, err := compileMarkerRegex(format, style)
+	if err != nil {
+		return fmt.Errorf("compiling marker format '%s': %w", format, err)
+	}
+	const project, iid = "group/project", 123
+	marker := formatMarker(format, style, project, iid)
+	if ref := parseMarker(markerRegex, "Description\n\n"+marker, project); ref != fmt.Sprintf("%s#%d", project, iid) {
+		return fmt.Errorf("marker format '%s' can not be parsed again, got '%s' from '%s'", format, ref, marker)
+	}
+	if rewriteIssueReferences(marker, map[int]int64{iid: iid + 1}) != marker {
+		return fmt.Errorf("marker format '%s' contains an issue reference like #{iid} that would be rewritten, "+
+			"put text without whitespace before the #", format)
+	}
+	return nil
+}
+
+// parseMarker returns the GitLab issue reference like group/project#12 of the
+// issue marker in the body, or an empty string if it contains none. The marker
+// is added at the end of the body, so the last match is used in case the
+// description contains text that looks like a marker. Formats without project
+// refer to the given project.
+func parseMarker(markerRegex *regexp.Regexp, body, project string) string {
+	matches := markerRegex.FindAllStringSubmatch(body, -1)
+	if matches == nil {
+		return ""
+	}
+	match := matches[len(matches)-1]
+	if i := markerRegex.SubexpIndex("project"); i >= 0 {
+		project = match[i]
+	}
+	return project + "#" + match[markerRegex.SubexpIndex("iid")]
+}
+
+// markerRef returns the GitLab issue reference of the issue marker in the
+// body, or an empty string if it contains none.
+func (m *migrator) markerRef(body string) string {
+	return parseMarker(m.markerRegex, body, m.args.GitlabProject)
+}
+
+// gitlabIssueRef returns the full reference of a GitLab issue like group/project#12.
+func (m *migrator) gitlabIssueRef(issue *gitlab.Issue) string {
+	return fmt.Sprintf("%s#%d", m.args.GitlabProject, issue.IID)
+}
+
+// issueMarker returns the hidden marker that is embedded in the Gitea issue
+// body to identify the GitLab issue that it was migrated from.
+func (m *migrator) issueMarker(issue *gitlab.Issue) string {
+	return formatMarker(m.args.MarkerFormat, m.args.MarkerStyle, m.args.GitlabProject, issue.IID)
+}
+
+// issueBody returns the Gitea issue body for the GitLab issue, including the
+// marker that identifies the source issue.
+func (m *migrator) issueBody(issue *gitlab.Issue) string {
+	return issue.Description + "\n\n" + m.issueMarker(issue)
+}
+
+// issueTitle returns the Gitea issue title for the GitLab issue. With the
+// dedup suffix enabled, titles that are used by multiple GitLab issues get the
+// IID appended, to create them as distinct issues. The issue with the lowest
+// IID keeps the title unchanged, independent of the listing order.
+func (m *migrator) issueTitle(issue *gitlab.Issue) string {
+	title := m.normalizeEmoji(issue.Title)
+	if !m.args.DedupSuffix {
+		return title
+	}
+
+	iid, ok := m.seenTitles[title]
+	if !ok {
+		// issues created in GitLab after the scan keep their title if unused
+		m.seenTitles[title] = issue.IID
+		return title
+	}
+	if iid == issue.IID {
+		return title
+	}
+	return fmt.Sprintf("%s (IID %d)", title, issue.IID)
+}
+
+// scanIssueTitles stores the lowest IID of every GitLab issue title, to keep
+// the title unchanged for that issue when appending the dedup suffix.
+func (m *migrator) scanIssueTitles() error {
+	return m.listGitlabIssues("opened", func(issue *gitlab.Issue) error {
+		title := m.normalizeEmoji(issue.Title)
+		if iid, ok := m.seenTitles[title]; !ok || issue.IID < iid {
+			m.seenTitles[title] = issue.IID
+		}
+		return nil
+	})
+}
+
+// gitlabIssueKey returns the key of a GitLab issue that is used to find the
+// matching existing Gitea issue.
+func (m *migrator) gitlabIssueKey(issue *gitlab.Issue) string {
+	if m.args.DedupBy == dedupTitle {
+		return m.issueTitle(issue)
+	}
+	return m.gitlabIssueRef(issue)
+}
+
+// giteaIssueKey returns the key of a Gitea issue that is used to match it with
+// a GitLab issue. An empty key is returned if the issue can not be matched.
+// The external ID strategy falls back to the marker for issues that were
+// created by this tool.
+func (m *migrator) giteaIssueKey(issue *gitea.Issue) string {
+	switch m.args.DedupBy {
+	case dedupTitle:
+		return issue.Title
+
+	case dedupExternalID:
+		if match := externalIDRegex.FindStringSubmatch(issue.Body); match != nil {
+			return match[1]
+		}
+	}
+
+	return m.markerRef(issue.Body)
+}
+
+// existingIssues returns a map of all Gitea issues that can be matched with a
+// GitLab issue, keyed by the configured deduplication strategy.
+func (m *migrator) existingIssues() (map[string]*gitea.Issue, error) {
+	giteaIssues, err := m.listGiteaIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make(map[string]*gitea.Issue, len(giteaIssues))
+	for _, issue := range giteaIssues {
+		if key := m.giteaIssueKey(issue); key != "" {
+			issues[key] = issue
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestIssueTitleDedupSuffix(t *testing.T) {
+	// listed by update time, the issue with the highest IID comes first
+	issues := []*gitlab.Issue{
+		{IID: 7, Title: "Crash"},
+		{IID: 2, Title: "Crash"},
+		{IID: 5, Title: "Crash"},
+		{IID: 3, Title: "Slow"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			writeJSON(t, w, []*gitlab.Issue{})
+			return
+		}
+		writeJSON(t, w, issues)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	m := newTestMigrator(t, arguments{DedupSuffix: true, DedupBy: dedupTitle, ResumeFromIID: 5})
+	m.gitlab = client
+
+	require.NoError(t, m.scanIssueTitles())
+	assert.Equal(t, "Crash (IID 7)", m.issueTitle(issues[0]))
+	assert.Equal(t, "Crash", m.issueTitle(issues[1]))
+	assert.Equal(t, "Crash (IID 5)", m.issueTitle(issues[2]))
+	assert.Equal(t, "Slow", m.issueTitle(issues[3]))
+	assert.Equal(t, "New", m.issueTitle(&gitlab.Issue{IID: 9, Title: "New"}))
+}
+
+func TestValidateMarkerFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		style  string
+		err    string
+	}{
+		{defaultMarkerFormat, markerStyleHTML, ""},
+		{"Migrated-From: {project}#{iid}", markerStyleText, ""},
+		{"gitlab-{iid}", markerStyleText, ""},
+		{"{iid}", markerStyleHTML, ""},
+		{"{project}", markerStyleHTML, "does not contain {iid}"},
+		{"{iid}", markerStyleText, "needs literal text"},
+		{"{project}#{iid}", markerStyleText, "needs literal text"},
+		{"Migrated-From: #{iid}", markerStyleText, "would be rewritten"},
+		{"#{iid}", markerStyleHTML, "would be rewritten"},
+		{"Migrated ({project} #{iid})", markerStyleText, "would be rewritten"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.style, func(t *testing.T) {
+			err := validateMarkerFormat(tt.format, tt.style)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseMarkerLastMatch(t *testing.T) {
+	markerRegex, err := compileMarkerRegex("Migrated-From: {project}#{iid}", markerStyleText)
+	require.NoError(t, err)
+
+	body := "Copied from the old issue:\n\nMigrated-From: other/project#3\n\nMigrated-From: group/project#12"
+	assert.Equal(t, "group/project#12", parseMarker(markerRegex, body, "group/project"))
+	assert.Empty(t, parseMarker(markerRegex, "No marker", "group/project"))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"regexp"
+)
+
+var emojiShortcodeRegex = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+
+// emojiShortcodes maps common GitLab emoji shortcodes to their unicode
+// characters. Gitea only supports a subset of the GitLab shortcodes.
+var emojiShortcodes = map[string]string{
+	"+1":                 "👍",
+	"-1":                 "👎",
+	"100":                "💯",
+	"alarm_clock":        "⏰",
+	"arrow_down":         "⬇️",
+	"arrow_up":           "⬆️",
+	"bangbang":           "‼️",
+	"beetle":             "🐞",
+	"bell":               "🔔",
+	"book":               "📖",
+	"boom":               "💥",
+	"bug":                "🐛",
+	"bulb":               "💡",
+	"calendar":           "📆",
+	"clap":               "👏",
+	"confused":           "😕",
+	"construction":       "🚧",
+	"cry":                "😢",
+	"eyes":               "👀",
+	"fire":               "🔥",
+	"gear":               "⚙️",
+	"grin":               "😁",
+	"grinning":           "😀",
+	"hammer":             "🔨",
+	"heart":              "❤️",
+	"heavy_check_mark":   "✔️",
+	"hourglass":          "⌛",
+	"information_source": "ℹ️",
+	"joy":                "😂",
+	"key":                "🔑",
+	"laughing":           "😆",
+	"lock":               "🔒",
+	"mag":                "🔍",
+	"memo":               "📝",
+	"no_entry":           "⛔",
+	"ok_hand":            "👌",
+	"package":            "📦",
+	"pencil":             "📝",
+	"pencil2":            "✏️",
+	"point_right":        "👉",
+	"pray":               "🙏",
+	"question":           "❓",
+	"rocket":             "🚀",
+	"rotating_light":     "🚨",
+	"sad":                "😞",
+	"scream":             "😱",
+	"see_no_evil":        "🙈",
+	"slight_smile":       "🙂",
+	"smile":              "😄",
+	"smiley":             "😃",
+	"sparkles":           "✨",
+	"star":               "⭐",
+	"stop_sign":          "🛑",
+	"sunglasses":         "😎",
+	"tada":               "🎉",
+	"thinking":           "🤔",
+	"thumbsdown":         "👎",
+	"thumbsup":           "👍",
+	"warning":            "⚠️",
+	"white_check_mark":   "✅",
+	"wink":               "😉",
+	"wrench":             "🔧",
+	"x":                  "❌",
+	"zap":                "⚡",
+}
+
+// normalizeEmoji replaces known GitLab emoji shortcodes like :tada: in the
+// text with their unicode characters if emoji normalization is enabled.
+// Unknown shortcodes and shortcodes inside code are left untouched.
+func (m *migrator) normalizeEmoji(s string) string {
+	if !m.args.NormalizeEmoji {
+		return s
+	}
+
+	return replaceOutsideCode(s, func(text string) string {
+		return emojiShortcodeRegex.ReplaceAllStringFunc(text, func(shortcode string) string {
+			if emoji, ok := emojiShortcodes[shortcode[1:len(shortcode)-1]]; ok {
+				return emoji
+			}
+			return shortcode
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmoji(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"known", "Release :tada:", "Release 🎉"},
+		{"multiple", ":+1: and :-1:", "👍 and 👎"},
+		{"unknown", "keep :not_an_emoji:", "keep :not_an_emoji:"},
+		{"time", "at 10:30:00", "at 10:30:00"},
+		{"inline code", "use `:tada:` for :tada:", "use `:tada:` for 🎉"},
+		{"fenced code", "```\n:tada:\n```\n:tada:", "```\n:tada:\n```\n🎉"},
+		{"unclosed backtick", ":tada: `:tada:", "🎉 `:tada:"},
+	}
+
+	m := newTestMigrator(t, arguments{NormalizeEmoji: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, m.normalizeEmoji(tt.input))
+		})
+	}
+}
+
+func TestNormalizeEmojiDisabled(t *testing.T) {
+	m := newTestMigrator(t, arguments{})
+	assert.Equal(t, "Release :tada:", m.normalizeEmoji("Release :tada:"))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cornelk/gotokit/log"
+)
+
+// phaseMigratingIssues is the name of the issue migration phase.
+const phaseMigratingIssues = "migrating issues"
+
+// ItemFailure describes a failed item of a migration phase.
+type ItemFailure struct {
+	Phase string
+	Item  string
+	Err   error
+	// Skipped is set for items that were skipped in interactive mode, the
+	// migration continued after them.
+	Skipped bool
+}
+
+// MigrationError is returned by migrateProject if a migration phase failed.
+// Besides the error of the failed phase, it lists the failed items of all
+// phases that ran, including the ones skipped in interactive mode.
+type MigrationError struct {
+	phase    string
+	err      error
+	failures []ItemFailure
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.phase, e.err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.err
+}
+
+// Phase returns the name of the failed migration phase.
+func (e *MigrationError) Phase() string {
+	return e.phase
+}
+
+// Failures returns the failed items in the order they failed.
+func (e *MigrationError) Failures() []ItemFailure {
+	return e.failures
+}
+
+// addFailure records a failed item of the running migration phase.
+func (m *migrator) addFailure(item string, err error, skipped bool) {
+	m.failures = append(m.failures, ItemFailure{
+		Phase:   m.phase,
+		Item:    item,
+		Err:     err,
+		Skipped: skipped,
+	})
+}
+
+// runPhase runs a migration phase. If it fails, a MigrationError is returned.
+func (m *migrator) runPhase(phase string, fn func() error) error {
+	m.phase = phase
+	m.logger.Info(strings.ToUpper(phase[:1]) + phase[1:])
+
+	if err := fn(); err != nil {
+		return &MigrationError{
+			phase:    phase,
+			err:      err,
+			failures: append([]ItemFailure(nil), m.failures...),
+		}
+	}
+	return nil
+}
+
+// finishMigration waits for pending webhook events and reports the API calls
+// and the failed and skipped items of the migration. A failed migration exits
+// the program, after writing the migration state if the API call budget is
+// exhausted.
+func (m *migrator) finishMigration(err error) {
+	m.webhooks.Wait()
+	m.logger.Info("API calls", log.Int64("count", m.apiCalls.calls.Load()))
+
+	if err == nil {
+		if len(m.failures) > 0 {
+			m.reportFailures(m.failures)
+			m.logger.Warn("Migration finished with skipped items", log.Int("count", len(m.failures)))
+			return
+		}
+		m.logger.Info("Migration finished successfully")
+		return
+	}
+
+	var migrationErr *MigrationError
+	if errors.As(err, &migrationErr) && errors.Is(err, errAPIBudgetExhausted) {
+		if err := m.writeBudgetState(migrationErr); err != nil {
+			m.logger.Fatal("Writing migration state failed", log.Err(err))
+		}
+		os.Exit(1)
+	}
+	if migrationErr != nil {
+		m.reportFailures(migrationErr.Failures())
+	}
+	m.logger.Fatal("Migrating the project failed", log.Err(err))
+}
+
+// reportFailures logs the failed items, including the skipped ones.
+func (m *migrator) reportFailures(failures []ItemFailure) {
+	for _, failure := range failures {
+		m.logger.Error("Failed item",
+			log.String("phase", failure.Phase),
+			log.String("item", failure.Item),
+			log.Bool("skipped", failure.Skipped),
+			log.Err(failure.Err),
+		)
+	}
+}
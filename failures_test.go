@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishMigrationReportsSkippedItems(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := createLogger(&buf, newRedactor())
+	require.NoError(t, err)
+
+	m := newTestMigrator(t, arguments{})
+	m.logger = logger
+	m.apiCalls = &apiCallCounter{}
+	m.phase = phaseMigratingIssues
+	m.addFailure("Issue 7", errors.New("creating issue: status 500"), true)
+
+	m.finishMigration(nil)
+
+	output := buf.String()
+	assert.Contains(t, output, "Issue 7")
+	assert.Contains(t, output, "creating issue: status 500")
+	assert.Contains(t, output, "Migration finished with skipped items")
+	assert.NotContains(t, output, "Migration finished successfully")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	placeholderLabelName  = "placeholder"
+	placeholderLabelColor = "#ededed"
+)
+
+// fillIssueGaps creates a closed placeholder Gitea issue for every IID that
+// is missing in the GitLab issue sequence because the issue was deleted, so
+// that references to every IID can be resolved.
+func (m *migrator) fillIssueGaps() error {
+	gaps, err := m.gitlabIssueGaps()
+	if err != nil {
+		return err
+	}
+	if len(gaps) == 0 {
+		m.logger.Info("No gaps in the GitLab issue numbering found")
+		return nil
+	}
+
+	giteaIssues, err := m.existingIssues()
+	if err != nil {
+		return err
+	}
+	giteaLabels, err := m.giteaLabels()
+	if err != nil {
+		return err
+	}
+	o := gitea.CreateLabelOption{
+		Name:        placeholderLabelName,
+		Description: "Placeholder for a deleted GitLab issue",
+		Color:       placeholderLabelColor,
+	}
+	if err = m.ensureLabel(giteaLabels, o); err != nil {
+		return err
+	}
+
+	for _, iid := range gaps {
+		placeholder := &gitlab.Issue{
+			IID:         iid,
+			Title:       fmt.Sprintf("(deleted GitLab issue #%d)", iid),
+			Description: "This GitLab issue was deleted.",
+		}
+		if _, ok := giteaIssues[m.gitlabIssueKey(placeholder)]; ok {
+			continue
+		}
+
+		err := m.migrateItem(fmt.Sprintf("Placeholder %d", iid), func() error {
+			return m.createPlaceholder(placeholder, giteaLabels[placeholderLabelName])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createPlaceholder creates the closed Gitea issue for a deleted GitLab issue.
+// Placeholders have no GitLab issue, so they skip the GitLab lookups and issue
+// details of migrated issues and are neither mapped nor sent as events.
+func (m *migrator) createPlaceholder(placeholder *gitlab.Issue, label *gitea.Label) error {
+	o := gitea.CreateIssueOption{
+		Title:  m.issueTitle(placeholder),
+		Body:   m.issueBody(placeholder),
+		Closed: true,
+		Labels: []int64{label.ID},
+	}
+	created, err := m.createIssue(o, "")
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Created placeholder issue", log.String("title", o.Title), log.Int64("index", created.Index))
+	return nil
+}
+
+// gitlabIssueGaps returns the IIDs up to the highest IID of the project that
+// are not used by any open or closed GitLab issue. IIDs below the resume IID
+// are skipped, as their issues are not listed.
+func (m *migrator) gitlabIssueGaps() ([]int, error) {
+	used := map[int]struct{}{}
+	var highest int
+	for _, state := range []string{"opened", "closed"} {
+		err := m.forEachGitlabIssue(state, func(issue *gitlab.Issue) error {
+			used[issue.IID] = struct{}{}
+			highest = max(highest, issue.IID)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing %s GitLab issues: %w", state, err)
+		}
+	}
+
+	var gaps []int
+	for iid := max(1, m.args.ResumeFromIID); iid <= highest; iid++ {
+		if _, ok := used[iid]; !ok {
+			gaps = append(gaps, iid)
+		}
+	}
+	return gaps, nil
+}
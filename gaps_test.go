@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestCreatePlaceholder(t *testing.T) {
+	var created gitea.CreateIssueOption
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/repos/owner/repo/issues": func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, gitea.Issue{Index: 4, Title: created.Title})
+		},
+	})
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+
+	// without GitLab client, any GitLab lookup for the deleted issue panics
+	m := newTestMigrator(t, arguments{
+		GitlabProject: "group/project",
+		MarkerFormat:  defaultMarkerFormat,
+		MarkerStyle:   markerStyleHTML,
+		TimeMode:      timeModeDetailed,
+		MapCSV:        "issues.csv",
+	})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+
+	placeholder := &gitlab.Issue{IID: 3, Title: "(deleted GitLab issue #3)", Description: "This GitLab issue was deleted."}
+	require.NoError(t, m.createPlaceholder(placeholder, &gitea.Label{ID: 9, Name: placeholderLabelName}))
+
+	assert.Equal(t, "(deleted GitLab issue #3)", created.Title)
+	assert.True(t, created.Closed)
+	assert.Equal(t, []int64{9}, created.Labels)
+	assert.Contains(t, created.Body, "<!-- gitlab2gitea:group/project#3 -->")
+	assert.Empty(t, m.issueMappings)
+}
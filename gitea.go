@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// giteaRequest executes a raw Gitea API request for endpoints or options that
+// are not supported by the Gitea SDK. The body and result are JSON encoded,
+// both can be nil.
+func (m *migrator) giteaRequest(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := strings.TrimSuffix(m.args.GiteaServer, "/") + "/api/v1" + path
+	req, err := http.NewRequestWithContext(context.Background(), method, u, reader)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+m.args.GiteaToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err = json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
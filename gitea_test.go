@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGiteaServer returns a fake Gitea server that serves the version and
+// the given handlers.
+func newTestGiteaServer(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/version", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]string{"version": "1.22.0"})
+	})
+	for pattern, handler := range handlers {
+		mux.HandleFunc(pattern, handler)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestGiteaLabelsPaging(t *testing.T) {
+	pages := map[string][]*gitea.Label{
+		"1": {{ID: 1, Name: "bug"}, {ID: 2, Name: "feature"}},
+		"2": {{ID: 3, Name: "docs"}},
+	}
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/repos/owner/repo/labels": func(w http.ResponseWriter, r *http.Request) {
+			labels, ok := pages[r.URL.Query().Get("page")]
+			if !ok {
+				labels = []*gitea.Label{}
+			}
+			writeJSON(t, w, labels)
+		},
+	})
+
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+	m := newTestMigrator(t, arguments{})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+
+	labels, err := m.giteaLabels()
+	require.NoError(t, err)
+	assert.Len(t, labels, 3)
+	for _, name := range []string{"bug", "feature", "docs"} {
+		assert.Contains(t, labels, name)
+	}
+}
+
+func TestGiteaServerURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   string
+		expected string
+	}{
+		{"root", "https://gitea.example.com", "https://gitea.example.com"},
+		{"root with slash", "https://gitea.example.com/", "https://gitea.example.com"},
+		{"subpath", "https://example.com/git/", "https://example.com/git"},
+		{"api suffix", "https://example.com/git/api/v1/", "https://example.com/git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := giteaServerURL(tt.server)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, server)
+		})
+	}
+}
+
+func TestGiteaServerURLInvalid(t *testing.T) {
+	for _, server := range []string{"ftp://example.com", "example.com/git"} {
+		_, err := giteaServerURL(server)
+		assert.Error(t, err, server)
+	}
+}
+
+func TestGiteaClientErrors(t *testing.T) {
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/user": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]string{"message": "token is required"})
+		},
+	})
+
+	m := newTestMigrator(t, arguments{
+		GiteaServer:  server.URL + "/wrong/",
+		GiteaProject: "owner/repo",
+	})
+	m.httpClient = server.Client()
+	_, err := m.giteaClient()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "check the server URL and subpath")
+
+	m.args.GiteaServer = server.URL
+	_, err = m.giteaClient()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "check the token")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitlabVersion is the major and minor version of a GitLab instance.
+type gitlabVersion struct {
+	major int
+	minor int
+}
+
+// minGitlabVersion is the oldest GitLab version that supports all endpoints
+// that are required for the migration.
+var minGitlabVersion = gitlabVersion{major: 11, minor: 0}
+
+func (v gitlabVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v gitlabVersion) less(other gitlabVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	return v.minor < other.minor
+}
+
+// parseGitlabVersion parses a GitLab version like 16.11.2-ee.
+func parseGitlabVersion(s string) (gitlabVersion, error) {
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) < 2 {
+		return gitlabVersion{}, fmt.Errorf("invalid GitLab version '%s'", s)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return gitlabVersion{}, fmt.Errorf("invalid GitLab major version '%s': %w", s, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return gitlabVersion{}, fmt.Errorf("invalid GitLab minor version '%s': %w", s, err)
+	}
+	return gitlabVersion{major: major, minor: minor}, nil
+}
+
+// checkGitlabVersion detects the version of the GitLab instance and fails if
+// it is older than the minimum version. Optional features that need a newer
+// version are disabled.
+func (m *migrator) checkGitlabVersion(client *gitlab.Client) error {
+	v, _, err := client.Version.GetVersion()
+	if err != nil {
+		return fmt.Errorf("getting GitLab version: %w", err)
+	}
+	m.gitlabVersion, err = parseGitlabVersion(v.Version)
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Detected GitLab version", log.String("version", v.Version))
+
+	if m.gitlabVersion.less(minGitlabVersion) {
+		return fmt.Errorf("GitLab version %s is not supported, at least %s is required", v.Version, minGitlabVersion)
+	}
+
+	features := []struct {
+		flag    string
+		enabled *bool
+		version gitlabVersion
+	}{
+		{"--iterationsasmilestones", &m.args.IterationsAsMilestones, gitlabVersion{major: 13, minor: 5}},
+		{"--packagenotes", &m.args.PackageNotes, gitlabVersion{major: 11, minor: 8}},
+	}
+	for _, feature := range features {
+		if *feature.enabled && m.gitlabVersion.less(feature.version) {
+			m.logger.Warn("Disabling option that is not supported by the GitLab version",
+				log.String("option", feature.flag),
+				log.String("required_version", feature.version.String()),
+			)
+			*feature.enabled = false
+		}
+	}
+	return nil
+}
@@ -6,7 +6,9 @@ require (
 	code.gitea.io/sdk/gitea v0.20.0
 	github.com/alexflint/go-arg v1.5.1
 	github.com/cornelk/gotokit v0.0.0-20241114001809-45d9d46aa03d
+	github.com/stretchr/testify v1.10.0
 	gitlab.com/gitlab-org/api/client-go v0.119.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -21,11 +23,9 @@ require (
 	github.com/hashicorp/go-version v1.6.0 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/oauth2 v0.24.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/time v0.8.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
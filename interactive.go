@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Actions that can be chosen when migrating an item fails in interactive mode.
+const (
+	failureRetry    = "r"
+	failureSkip     = "s"
+	failureAbort    = "a"
+	failureRetryAll = "R"
+	failureSkipAll  = "S"
+)
+
+// maxAutoRetries is the number of automatic retries of a failed item after
+// retry all was chosen, before the user is asked again.
+const maxAutoRetries = 3
+
+// migrationPlan contains the number of items that a migration will write.
+type migrationPlan struct {
+	issues     int
+	labels     int
+	milestones int
+}
+
+// isTerminal returns whether the given file is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompt prints the question and returns the trimmed answer read from the
+// standard input.
+func (m *migrator) prompt(question string) (string, error) {
+	fmt.Print(m.redactor.redact(question))
+
+	answer, err := m.input.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+		return "", fmt.Errorf("reading answer: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// confirmMigration asks the user to confirm the planned migration. The prompt
+// is skipped if stdout is not a terminal to avoid blocking unattended runs.
+func (m *migrator) confirmMigration() (bool, error) {
+	if !isTerminal(os.Stdout) {
+		m.logger.Info("Skipping confirmation as stdout is not a terminal")
+		return true, nil
+	}
+
+	plan, err := m.planMigration()
+	if err != nil {
+		return false, fmt.Errorf("planning migration: %w", err)
+	}
+
+	question := fmt.Sprintf("Migrate %d issues, %d labels, %d milestones into %s/%s? [y/N] ",
+		plan.issues, plan.labels, plan.milestones, m.giteaOwner, m.giteaRepo)
+	answer, err := m.prompt(question)
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// planMigration returns the number of items that the migration will write,
+// without writing anything to Gitea.
+func (m *migrator) planMigration() (migrationPlan, error) {
+	var plan migrationPlan
+
+	existingMilestones, err := m.giteaMilestones()
+	if err != nil {
+		return plan, err
+	}
+	err = m.forEachGitlabMilestone("active", func(milestone *gitlab.Milestone) error {
+		if _, ok := existingMilestones[milestone.Title]; ok || !m.milestoneSelected(milestone.Title) {
+			return nil
+		}
+		if m.fuzzyMilestone(milestone.Title, existingMilestones) == nil {
+			plan.milestones++
+		}
+		return nil
+	})
+	if err != nil {
+		return plan, fmt.Errorf("listing milestones: %w", err)
+	}
+
+	existingLabels, err := m.giteaLabels()
+	if err != nil {
+		return plan, err
+	}
+	err = m.forEachGitlabLabel(func(label *gitlab.Label) error {
+		if _, ok := existingLabels[m.giteaLabelName(label.Name)]; !ok {
+			plan.labels++
+		}
+		return nil
+	})
+	if err != nil {
+		return plan, fmt.Errorf("listing labels: %w", err)
+	}
+
+	err = m.forEachGitlabIssue("opened", func(*gitlab.Issue) error {
+		plan.issues++
+		return nil
+	})
+	if err != nil {
+		return plan, fmt.Errorf("listing issues: %w", err)
+	}
+
+	m.logger.Debug("Planned migration",
+		log.Int("issues", plan.issues),
+		log.Int("labels", plan.labels),
+		log.Int("milestones", plan.milestones),
+	)
+	return plan, nil
+}
+
+// migrateItem calls the given migration function of a single item. If it fails
+// in interactive mode, the user is asked whether to retry or skip the item or
+// to abort the migration. Non-interactive runs return the error directly.
+func (m *migrator) migrateItem(item string, fn func() error) error {
+	var autoRetries int
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if !m.args.Interactive || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) ||
+			errors.Is(err, errAPIBudgetExhausted) {
+			m.addFailure(item, err, false)
+			return err
+		}
+
+		action := m.failureAction
+		if action == failureRetryAll && autoRetries >= maxAutoRetries {
+			action = ""
+		}
+		if action == "" {
+			var askErr error
+			action, askErr = m.askFailureAction(item, err)
+			if askErr != nil {
+				m.addFailure(item, err, false)
+				return askErr
+			}
+		}
+
+		switch action {
+		case failureRetry:
+			autoRetries = 0
+		case failureRetryAll:
+			autoRetries++
+		case failureSkip, failureSkipAll:
+			m.logger.Warn("Skipping failed item", log.String("item", item))
+			m.addFailure(item, err, true)
+			return nil
+		default:
+			m.addFailure(item, err, false)
+			return fmt.Errorf("%s: %w", item, err)
+		}
+	}
+}
+
+// askFailureAction asks the user how to handle a failed item until a valid
+// action is chosen. Choices for all following failures are remembered.
+func (m *migrator) askFailureAction(item string, itemErr error) (string, error) {
+	question := fmt.Sprintf("%s failed: %s. [r]etry / [s]kip / [a]bort / [R]etry all / [S]kip all? ", item, itemErr)
+	for {
+		answer, err := m.prompt(question)
+		if err != nil {
+			return "", err
+		}
+
+		switch answer {
+		case failureRetryAll, failureSkipAll:
+			m.failureAction = answer
+			return answer, nil
+		case failureRetry, failureSkip, failureAbort:
+			return answer, nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitlabIterationClosed is the state of a closed GitLab iteration.
+const gitlabIterationClosed = 3
+
+// migrateIterations creates a Gitea milestone for every GitLab iteration of
+// the groups of the project. GitLab instances without iterations are skipped.
+func (m *migrator) migrateIterations() error {
+	iterations, err := m.gitlabIterations()
+	if err != nil {
+		return err
+	}
+	existing, err := m.giteaMilestones()
+	if err != nil {
+		return err
+	}
+
+	for _, iteration := range iterations {
+		title := iterationTitle(iteration.Title, iteration.StartDate, iteration.DueDate)
+		if _, ok := existing[title]; ok {
+			continue
+		}
+
+		o := gitea.CreateMilestoneOption{
+			Title:       title,
+			Description: iterationDescription(iteration),
+			State:       gitea.StateOpen,
+			Deadline:    (*time.Time)(iteration.DueDate),
+		}
+		if iteration.State == gitlabIterationClosed {
+			o.State = gitea.StateClosed
+		}
+
+		err := m.migrateItem("Iteration "+title, func() error {
+			created, _, err := m.gitea.CreateMilestone(m.giteaOwner, m.giteaRepo, o)
+			if err != nil {
+				return err
+			}
+			m.logger.Info("Created milestone from iteration", log.String("title", title))
+			m.sendEvent(eventMilestone, int64(iteration.ID), created.ID, eventCreated)
+			return nil
+		})
+		if err != nil {
+			m.sendEvent(eventMilestone, int64(iteration.ID), 0, eventFailed)
+			return err
+		}
+	}
+	return nil
+}
+
+// gitlabIterations returns all iterations of the project, including the ones
+// of its ancestor groups. If iterations are not available, none are returned.
+func (m *migrator) gitlabIterations() ([]*gitlab.ProjectIteration, error) {
+	includeAncestors := true
+	var iterations []*gitlab.ProjectIteration
+	for page := 1; ; page++ {
+		opt := &gitlab.ListProjectIterationsOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    page,
+				PerPage: gitlabPageSize,
+			},
+			IncludeAncestors: &includeAncestors,
+		}
+
+		gitlabIterations, _, err := m.gitlab.ProjectIterations.ListProjectIterations(m.gitlabProjectID, opt, nil)
+		if err != nil {
+			if apiUnavailable(err) {
+				m.logger.Info("Iterations are not available", log.Err(err))
+				return nil, nil
+			}
+			return nil, fmt.Errorf("listing GitLab iterations: %w", err)
+		}
+		logPage(m, "GitLab iterations", page, gitlabIterations, func(iteration *gitlab.ProjectIteration) int64 {
+			return int64(iteration.ID)
+		})
+		if len(gitlabIterations) == 0 {
+			return iterations, nil
+		}
+		iterations = append(iterations, gitlabIterations...)
+	}
+}
+
+// iterationTitle returns the Gitea milestone title of a GitLab iteration.
+// Iterations of cadences have no title and are named by their dates.
+func iterationTitle(title string, start, due *gitlab.ISOTime) string {
+	if title != "" {
+		return title
+	}
+	return fmt.Sprintf("Iteration %s - %s", isoDate(start), isoDate(due))
+}
+
+// iterationDescription returns the Gitea milestone description of a GitLab
+// iteration, which keeps the start date that Gitea milestones do not support.
+func iterationDescription(iteration *gitlab.ProjectIteration) string {
+	description := fmt.Sprintf("GitLab iteration starting %s", isoDate(iteration.StartDate))
+	if iteration.Description == "" {
+		return description
+	}
+	return iteration.Description + "\n\n" + description
+}
+
+// isoDate returns the date formatted as YYYY-MM-DD or a placeholder if it is
+// not set.
+func isoDate(date *gitlab.ISOTime) string {
+	if date == nil {
+		return "unknown"
+	}
+	return date.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+// gitlabScopeSeparator separates the scope from the value of a GitLab scoped
+// label like status::open.
+const gitlabScopeSeparator = "::"
+
+// createExclusiveLabelOption extends the SDK label creation options with the
+// exclusive flag that is not supported by the SDK yet.
+type createExclusiveLabelOption struct {
+	gitea.CreateLabelOption
+	Exclusive bool `json:"exclusive"`
+}
+
+// giteaLabelName returns the Gitea label name of a GitLab label. If exclusive
+// scopes are enabled, GitLab scoped labels like status::open are mapped to the
+// Gitea exclusive scope notation status/open.
+func (m *migrator) giteaLabelName(name string) string {
+	if m.exclusiveScope(name) == "" {
+		return name
+	}
+	return strings.ReplaceAll(name, gitlabScopeSeparator, "/")
+}
+
+// exclusiveScope returns the scope of a GitLab scoped label if exclusive scopes
+// are enabled, otherwise or for labels without scope an empty string is returned.
+func (m *migrator) exclusiveScope(name string) string {
+	if !m.args.ExclusiveScopes {
+		return ""
+	}
+
+	i := strings.LastIndex(name, gitlabScopeSeparator)
+	if i <= 0 {
+		return ""
+	}
+	return name[:i]
+}
+
+// createLabel creates a label. Names are passed unchanged, including emoji,
+// and rejected names are reported with the name in the error.
+func (m *migrator) createLabel(o gitea.CreateLabelOption) (*gitea.Label, error) {
+	if !utf8.ValidString(o.Name) {
+		return nil, fmt.Errorf("label name '%s' is not valid UTF-8", o.Name)
+	}
+	label, _, err := m.gitea.CreateLabel(m.giteaOwner, m.giteaRepo, o)
+	if err != nil {
+		return nil, fmt.Errorf("creating label '%s': %w", o.Name, err)
+	}
+	return label, nil
+}
+
+// createExclusiveLabel creates a label that belongs to a Gitea exclusive scope.
+func (m *migrator) createExclusiveLabel(o gitea.CreateLabelOption) (*gitea.Label, error) {
+	path := fmt.Sprintf("/repos/%s/%s/labels", url.PathEscape(m.giteaOwner), url.PathEscape(m.giteaRepo))
+	opt := createExclusiveLabelOption{
+		CreateLabelOption: o,
+		Exclusive:         true,
+	}
+	label := &gitea.Label{}
+	if err := m.giteaRequest(http.MethodPost, path, opt, label); err != nil {
+		return nil, fmt.Errorf("creating exclusive label '%s': %w", o.Name, err)
+	}
+	return label, nil
+}
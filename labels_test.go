@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestGiteaLabelName(t *testing.T) {
+	tests := []struct {
+		name            string
+		label           string
+		exclusiveScopes bool
+		expectedName    string
+		expectedScope   string
+	}{
+		{"scoped", "status::open", true, "status/open", "status"},
+		{"nested scope", "a::b::c", true, "a/b/c", "a::b"},
+		{"no scope", "bug", true, "bug", ""},
+		{"leading separator", "::open", true, "::open", ""},
+		{"flag off", "status::open", false, "status::open", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMigrator(t, arguments{ExclusiveScopes: tt.exclusiveScopes})
+			assert.Equal(t, tt.expectedName, m.giteaLabelName(tt.label))
+			assert.Equal(t, tt.expectedScope, m.exclusiveScope(tt.label))
+		})
+	}
+}
+
+func TestGiteaIssueLabelsOneLabelPerScope(t *testing.T) {
+	m := newTestMigrator(t, arguments{ExclusiveScopes: true})
+	giteaLabels := map[string]*gitea.Label{
+		"status/open":   {ID: 1, Name: "status/open"},
+		"status/review": {ID: 2, Name: "status/review"},
+		"bug":           {ID: 3, Name: "bug"},
+	}
+	issue := &gitlab.Issue{
+		Labels: gitlab.Labels{"status::open", "bug", "status::review"},
+	}
+
+	assert.Equal(t, []int64{1, 3}, m.giteaIssueLabels(issue, giteaLabels))
+}
+
+func TestGiteaIssueLabelsKeepOrder(t *testing.T) {
+	m := newTestMigrator(t, arguments{})
+	giteaLabels := map[string]*gitea.Label{
+		"alpha": {ID: 1, Name: "alpha"},
+		"beta":  {ID: 2, Name: "beta"},
+		"gamma": {ID: 3, Name: "gamma"},
+	}
+	issue := &gitlab.Issue{
+		Labels: gitlab.Labels{"gamma", "alpha", "beta"},
+	}
+
+	assert.Equal(t, []int64{3, 1, 2}, m.giteaIssueLabels(issue, giteaLabels))
+}
+
+func TestEnsureLabelEmojiName(t *testing.T) {
+	const name = "🐛 bug"
+	var labels []*gitea.Label
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/repos/owner/repo/labels": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				if r.URL.Query().Get("page") != "1" {
+					writeJSON(t, w, []*gitea.Label{})
+					return
+				}
+				writeJSON(t, w, labels)
+				return
+			}
+
+			var o gitea.CreateLabelOption
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&o))
+			label := &gitea.Label{ID: int64(len(labels) + 1), Name: o.Name, Color: o.Color}
+			labels = append(labels, label)
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, label)
+		},
+	})
+
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+	m := newTestMigrator(t, arguments{})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+
+	o := gitea.CreateLabelOption{Name: name, Color: "#ff0000"}
+	giteaLabels, err := m.giteaLabels()
+	require.NoError(t, err)
+	require.NoError(t, m.ensureLabel(giteaLabels, o))
+	require.Len(t, labels, 1)
+	assert.Equal(t, name, labels[0].Name)
+
+	giteaLabels, err = m.giteaLabels()
+	require.NoError(t, err)
+	assert.Contains(t, giteaLabels, name)
+	require.NoError(t, m.ensureLabel(giteaLabels, o))
+	assert.Len(t, labels, 1)
+
+	o.Name = "\xff"
+	assert.ErrorContains(t, m.ensureLabel(giteaLabels, o), "not valid UTF-8")
+}
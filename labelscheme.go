@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gopkg.in/yaml.v3"
+)
+
+var labelColorRegex = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+// labelScheme is a standard set of labels that is applied to every migrated
+// project, it is read from a YAML file like:
+//
+//	labels:
+//	  - name: bug
+//	    color: "#d73a4a"
+//	    description: Something is not working
+type labelScheme struct {
+	Labels []schemeLabel `yaml:"labels"`
+}
+
+type schemeLabel struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+}
+
+// readLabelScheme reads and validates the label scheme file. Labels without
+// name or with an invalid color and duplicate names are reported as errors.
+func readLabelScheme(fileName string) (map[string]schemeLabel, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("reading label scheme: %w", err)
+	}
+
+	var scheme labelScheme
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&scheme); err != nil {
+		return nil, fmt.Errorf("decoding label scheme: %w", err)
+	}
+
+	labels := make(map[string]schemeLabel, len(scheme.Labels))
+	var errs []error
+	for i, label := range scheme.Labels {
+		switch {
+		case label.Name == "":
+			errs = append(errs, fmt.Errorf("label %d has no name", i+1))
+		case !labelColorRegex.MatchString(label.Color):
+			errs = append(errs, fmt.Errorf("label '%s' has invalid color '%s'", label.Name, label.Color))
+		default:
+			if _, ok := labels[label.Name]; ok {
+				errs = append(errs, fmt.Errorf("label '%s' is defined more than once", label.Name))
+				continue
+			}
+			labels[label.Name] = label
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid label scheme %s: %w", fileName, err)
+	}
+	return labels, nil
+}
+
+// applyLabelScheme overrides the color and description of a label with the
+// ones of the label scheme entry of the same name. Conflicting values of the
+// GitLab label are logged.
+func (m *migrator) applyLabelScheme(o *gitea.CreateLabelOption) {
+	label, ok := m.labelScheme[o.Name]
+	if !ok {
+		return
+	}
+
+	color := schemeColor(label.Color)
+	if !strings.EqualFold(color, o.Color) || label.Description != o.Description {
+		m.logger.Info("Label scheme overrides GitLab label",
+			log.String("name", o.Name),
+			log.String("gitlab_color", o.Color),
+			log.String("scheme_color", color),
+		)
+	}
+	o.Color = color
+	o.Description = label.Description
+}
+
+// migrateSchemeLabels creates the labels of the label scheme that do not
+// exist in Gitea yet, after the GitLab labels were migrated.
+func (m *migrator) migrateSchemeLabels() error {
+	existing, err := m.giteaLabels()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(m.labelScheme))
+	for name := range m.labelScheme {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if _, ok := existing[name]; ok {
+			continue
+		}
+
+		label := m.labelScheme[name]
+		o := gitea.CreateLabelOption{
+			Name:        label.Name,
+			Color:       schemeColor(label.Color),
+			Description: label.Description,
+		}
+		err := m.migrateItem("Label "+o.Name, func() error {
+			if _, err := m.createLabel(o); err != nil {
+				return err
+			}
+			m.logger.Info("Created scheme label",
+				log.String("name", o.Name),
+				log.String("color", o.Color),
+			)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemeColor returns the color of a label scheme entry with the leading #
+// that is optional in the scheme file.
+func schemeColor(color string) string {
+	return "#" + strings.TrimPrefix(color, "#")
+}
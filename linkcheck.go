@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cornelk/gotokit/log"
+)
+
+var (
+	absoluteLinkRegex = regexp.MustCompile(`https?://[^\s)\]>"'<]+`)
+	relativeLinkRegex = regexp.MustCompile(`\]\((/[^\s)]+)\)`)
+)
+
+// checkLinks checks that all links in the migrated Gitea issue bodies that
+// point to the Gitea server itself can be resolved. External links are skipped.
+func (m *migrator) checkLinks() error {
+	base, err := url.Parse(m.args.GiteaServer)
+	if err != nil {
+		return fmt.Errorf("parsing Gitea server URL: %w", err)
+	}
+
+	issues, err := m.listGiteaIssues()
+	if err != nil {
+		return err
+	}
+
+	checked := map[string]bool{}
+	var broken int
+
+	for _, issue := range issues {
+		for _, link := range internalLinks(base, issue.Body) {
+			ok, seen := checked[link]
+			if !seen {
+				ok = m.checkLink(link)
+				checked[link] = ok
+			}
+			if ok {
+				continue
+			}
+
+			broken++
+			m.logger.Warn("Broken link",
+				log.Int64("issue", issue.Index),
+				log.String("title", issue.Title),
+				log.String("link", link),
+			)
+		}
+	}
+
+	m.logger.Info("Link check finished",
+		log.Int("checked", len(checked)),
+		log.Int("broken", broken),
+	)
+	return nil
+}
+
+// checkLink returns whether the given link can be resolved on the Gitea server.
+func (m *migrator) checkLink(link string) bool {
+	status, err := m.requestLink(http.MethodHead, link)
+	if err == nil && status == http.StatusMethodNotAllowed {
+		status, err = m.requestLink(http.MethodGet, link)
+	}
+	if err != nil {
+		m.logger.Debug("Checking link failed", log.String("link", link), log.Err(err))
+		return false
+	}
+	return status < http.StatusBadRequest
+}
+
+// requestLink requests the given link using the Gitea token for authentication
+// and returns the response status code. The request is sent by the shared
+// HTTP client like API requests, but the links point to HTML pages.
+func (m *migrator) requestLink(method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(allowHTML(context.Background()), method, link, nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+m.args.GiteaToken)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("executing request: %w", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// internalLinks returns all absolute links of the body that point to the
+// given base URL and all relative links resolved against the base URL.
+func internalLinks(base *url.URL, body string) []string {
+	prefix := strings.TrimSuffix(base.String(), "/") + "/"
+	var links []string
+
+	for _, link := range absoluteLinkRegex.FindAllString(body, -1) {
+		if strings.HasPrefix(link, prefix) {
+			links = append(links, link)
+		}
+	}
+
+	for _, match := range relativeLinkRegex.FindAllStringSubmatch(body, -1) {
+		ref, err := url.Parse(match[1])
+		if err != nil {
+			continue
+		}
+		links = append(links, base.ResolveReference(ref).String())
+	}
+
+	return links
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/owner/repo/issues/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body>Issue</body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	m := newTestMigrator(t, arguments{})
+	m.apiCalls = &apiCallCounter{next: server.Client().Transport}
+	m.httpClient = &http.Client{
+		Transport: htmlResponseCheck{next: m.apiCalls},
+	}
+
+	assert.True(t, m.checkLink(server.URL+"/owner/repo/issues/1"))
+	assert.False(t, m.checkLink(server.URL+"/owner/repo/issues/2"))
+	assert.Equal(t, int64(2), m.apiCalls.calls.Load())
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"code.gitea.io/sdk/gitea"
@@ -12,32 +19,137 @@ import (
 	"github.com/cornelk/gotokit/env"
 	"github.com/cornelk/gotokit/log"
 	"gitlab.com/gitlab-org/api/client-go"
+	"gopkg.in/yaml.v3"
 )
 
 type arguments struct {
-	GitlabToken   string `arg:"--gitlabtoken,required" help:"token for GitLab API access"`
-	GitlabServer  string `arg:"--gitlabserver" help:"GitLab server URL with a trailing slash"`
-	GitlabProject string `arg:"--gitlabproject,required" help:"GitLab project name, use namespace/name"`
-	GiteaToken    string `arg:"--giteatoken,required" help:"token for Gitea API access"`
-	GiteaServer   string `arg:"--giteaserver,required" help:"Gitea server URL"`
-	GiteaProject  string `arg:"--giteaproject" help:"Gitea project name, use namespace/name. defaults to GitLab project name"`
+	GitlabToken            string              `arg:"--gitlabtoken,required" help:"token for GitLab API access"`
+	GitlabServer           string              `arg:"--gitlabserver" help:"GitLab server URL with a trailing slash"`
+	GitlabProject          string              `arg:"--gitlabproject,required" help:"GitLab project name, use namespace/name or the project URL"`
+	GiteaToken             string              `arg:"--giteatoken,required" help:"token for Gitea API access"`
+	GiteaServer            string              `arg:"--giteaserver,required" help:"Gitea server URL, can include a subpath like https://host/git/"`
+	GiteaProject           string              `arg:"--giteaproject" help:"Gitea project name, use namespace/name. defaults to GitLab project name"`
+	GiteaDefaultBranch     string              `arg:"--giteadefaultbranch" help:"set the default branch of the Gitea repo to the given branch, which has to exist"`
+	CopyDefaultBranch      bool                `arg:"--copydefaultbranch" help:"set the default branch of the Gitea repo to the default branch of the GitLab project if it exists"`
+	DebugPaging            bool                `arg:"--debugpaging" help:"log page number, item count and first/last item IDs of every listed page"`
+	MapCSV                 string              `arg:"--mapcsv" help:"write the mapping of GitLab issue IIDs to Gitea issue indices to this CSV file"`
+	VerifyBodies           bool                `arg:"--verifybodies" help:"fetch every migrated issue again and compare its body with the sent body"`
+	LinkCheck              bool                `arg:"--linkcheck" help:"check that links to the Gitea server in migrated issues can be resolved"`
+	IssueOrder             string              `arg:"--issueorder" default:"created" help:"order of listing GitLab issues: created or updated"`
+	ResumeFromIID          int                 `arg:"--resumefromiid" help:"skip GitLab issues with a lower IID and list issues in ascending order"`
+	LabelScheme            string              `arg:"--labelscheme" help:"YAML file of standard labels to create, overrides color and description of GitLab labels of the same name"`
+	ExclusiveScopes        bool                `arg:"--exclusivescopes" help:"create GitLab scoped labels like status::open as Gitea exclusive labels status/open"`
+	Interactive            bool                `arg:"--interactive" help:"ask for confirmation before writing to Gitea and how to handle failed items"`
+	CommentsInBody         bool                `arg:"--commentsinbody" help:"append the GitLab comments to the issue body in a comments section"`
+	NormalizeEmoji         bool                `arg:"--normalizeemoji" help:"convert GitLab emoji shortcodes like :tada: in issue titles and bodies to unicode"`
+	TimeMode               string              `arg:"--timemode" default:"none" help:"migration of GitLab spent time: none or detailed"`
+	DedupBy                string              `arg:"--dedupby" default:"marker" help:"match existing Gitea issues by: marker, title or externalid"`
+	MaxAPICalls            int64               `arg:"--maxapicalls" help:"stop the migration after this number of API requests, 0 for no limit"`
+	RequestTimeout         time.Duration       `arg:"--requesttimeout" default:"60s" help:"timeout of a single API request, 0 disables the timeout"`
+	SudoAuthors            bool                `arg:"--sudoauthors" help:"create issues as the Gitea user with the username of the GitLab author, requires a Gitea admin token"`
+	MarkerFormat           string              `arg:"--markerformat" default:"gitlab2gitea:{project}#{iid}" help:"template of the issue marker that identifies the GitLab issue, with {project} and {iid} placeholders"`
+	MarkerStyle            string              `arg:"--markerstyle" default:"html" help:"style of the issue marker: html to hide it in a comment or text"`
+	DedupSuffix            bool                `arg:"--dedupsuffix" help:"append the IID to duplicate GitLab issue titles when matching by title"`
+	Webhook                string              `arg:"--webhook" help:"URL to post a JSON event to after every migrated item"`
+	MovedIssues            bool                `arg:"--movedissues" help:"migrate GitLab issues moved to other projects as closed issues with a moved label"`
+	MilestoneInclude       []string            `arg:"--milestoneinclude,separate" help:"only migrate milestones with a title matching the glob pattern, can be repeated"`
+	MilestoneExclude       []string            `arg:"--milestoneexclude,separate" help:"skip milestones with a title matching the glob pattern, can be repeated, takes precedence over includes"`
+	IterationsAsMilestones bool                `arg:"--iterationsasmilestones" help:"create Gitea milestones for GitLab iterations and assign them to issues without milestone"`
+	OpenMilestonesOnly     bool                `arg:"--openmilestonesonly" help:"do not assign closed Gitea milestones to issues"`
+	MilestoneFuzzy         bool                `arg:"--milestonefuzzy" help:"match GitLab milestones to existing Gitea milestones with similar titles"`
+	MilestoneCloseDates    bool                `arg:"--milestoneclosedates" help:"add the closure date to the description of closed milestones, requires --reconcilemilestones"`
+	SkipRepoCheck          bool                `arg:"--skiprepocheck" help:"do not check that the Gitea repo exists on startup"`
+	FillGaps               bool                `arg:"--fillgaps" help:"create closed placeholder issues for IIDs of deleted GitLab issues"`
+	TwoPass                bool                `arg:"--twopass" help:"create all issues with title only first and fill in their content with rewritten references in a second pass, requires --rewriterefs"`
+	RewriteRefs            bool                `arg:"--rewriterefs" help:"rewrite GitLab issue references like #12 in migrated issues to the Gitea issue index"`
+	Concurrency            int                 `arg:"--concurrency" default:"4" help:"number of concurrent workers for rewriting issue references"`
+	ReconcileMilestones    bool                `arg:"--reconcilemilestones" help:"only set the state of existing Gitea milestones to the GitLab milestone state"`
+	PackageNotes           bool                `arg:"--packagenotes" help:"list GitLab packages and container images in a Gitea issue as republish checklist"`
+	Capabilities           bool                `arg:"--capabilities" help:"print the GitLab project data that will not be migrated and exit"`
+	MigrateComments        *migrateCommentsCmd `arg:"subcommand:migrate-comments" help:"migrate the comments of already migrated issues listed in an issue mapping file" yaml:"-"`
+	PrintConfig            bool                `arg:"--printconfig" help:"print the effective configuration as YAML with redacted tokens and exit" yaml:"-"`
 }
 
 func (arguments) Description() string {
 	return "Migrate labels, issues and milestones from GitLab to Gitea.\n"
 }
 
+// Supported orders of listing GitLab issues.
+const (
+	issueOrderCreated = "created"
+	issueOrderUpdated = "updated"
+)
+
+const gitlabPageSize = 100
+
 type migrator struct {
 	args   arguments
 	logger *log.Logger
+	input  *bufio.Reader
+
+	// redactor removes secrets from prompts, the logger uses it as output.
+	redactor redactor
+
+	// httpClient is shared by the GitLab and Gitea clients and applies the
+	// request timeout with retries, the API call budget and the HTML response
+	// check.
+	httpClient *http.Client
+	apiCalls   *apiCallCounter
+
+	// failureAction is the remembered interactive action for failed items.
+	failureAction string
+
+	// phase is the running migration phase and failures the failed items of
+	// all phases, they are returned as MigrationError.
+	phase    string
+	failures []ItemFailure
+
+	runID    string
+	webhooks sync.WaitGroup
+
+	// timeTracking is set if the Gitea repo has time tracking enabled.
+	timeTracking bool
+
+	// passOneIndices maps GitLab issue IIDs to Gitea issue indices after the
+	// first pass of the two pass issue migration.
+	passOneIndices map[int]int64
+
+	// labelScheme contains the labels of the label scheme file by name.
+	labelScheme map[string]schemeLabel
+
+	// currentIssueIID is the IID of the GitLab issue that is being migrated.
+	currentIssueIID int
 
-	gitlab          *gitlab.Client
-	gitlabProjectID int
+	// issueMappings contains the Gitea issues of all migrated GitLab issues.
+	issueMappings []issueMapping
+
+	// markerRegex parses the issue markers of the configured format.
+	markerRegex *regexp.Regexp
+
+	// giteaUsers caches whether Gitea users exist by name.
+	giteaUsers map[string]bool
+
+	// seenTitles maps the GitLab issue titles to the lowest GitLab IID of the
+	// issues with the title.
+	seenTitles map[string]int
+
+	// bodyMismatches contains the references of GitLab issues whose migrated
+	// bodies did not match on verification.
+	bodyMismatches []string
+
+	// fuzzyMilestones caches the fuzzy matched Gitea milestones by GitLab title.
+	fuzzyMilestones map[string]*gitea.Milestone
+
+	gitlab              *gitlab.Client
+	gitlabProjectID     int
+	gitlabDefaultBranch string
+	gitlabVersion       gitlabVersion
 
 	gitea          *gitea.Client
 	giteaProjectID int64
 	giteaRepo      string
 	giteaOwner     string
+	giteaUser      *gitea.User
 }
 
 func main() {
@@ -46,23 +158,49 @@ func main() {
 		fmt.Printf("Reading arguments failed: %s\n", err)
 		os.Exit(1)
 	}
+	if args.PrintConfig {
+		if err := printConfig(args); err != nil {
+			fmt.Printf("Printing config failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	logger, err := createLogger()
+	redact := newRedactor(args.GitlabToken, args.GiteaToken)
+	logger, err := createLogger(os.Stdout, redact)
 	if err != nil {
 		fmt.Printf("Creating logger failed: %s\n", err)
 		os.Exit(1)
 	}
+	if args.DebugPaging {
+		logger.SetLevel(log.DebugLevel)
+	}
 
-	m, err := newMigrator(args, logger)
+	m, err := newMigrator(args, logger, redact)
 	if err != nil {
 		logger.Fatal("Creating migrator failed", log.Err(err))
 	}
 
-	if err := m.migrateProject(); err != nil {
-		m.logger.Fatal("Migrating the project failed", log.Err(err))
+	if args.Capabilities {
+		if err := m.reportCapabilities(); err != nil {
+			m.logger.Fatal("Reporting capabilities failed", log.Err(err))
+		}
+		return
+	}
+
+	if args.Interactive {
+		confirmed, err := m.confirmMigration()
+		if err != nil {
+			m.logger.Fatal("Confirming the migration failed", log.Err(err))
+		}
+		if !confirmed {
+			m.logger.Info("Migration aborted")
+			return
+		}
 	}
 
-	m.logger.Info("Migration finished successfully")
+	err = m.migrateProject()
+	m.finishMigration(err)
 }
 
 func readArguments() (arguments, error) {
@@ -81,16 +219,107 @@ func readArguments() (arguments, error) {
 		return arguments{}, fmt.Errorf("parsing arguments: %w", err)
 	}
 
+	if err = args.validate(); err != nil {
+		return arguments{}, err
+	}
 	return args, nil
 }
 
-func createLogger() (*log.Logger, error) {
+// validate checks the values of arguments that go-arg can not validate itself.
+func (a arguments) validate() error {
+	switch a.IssueOrder {
+	case issueOrderCreated, issueOrderUpdated:
+	default:
+		return fmt.Errorf("invalid issue order '%s'", a.IssueOrder)
+	}
+
+	if a.ResumeFromIID < 0 {
+		return fmt.Errorf("invalid issue IID to resume from %d", a.ResumeFromIID)
+	}
+
+	switch a.TimeMode {
+	case timeModeNone, timeModeDetailed:
+	default:
+		return fmt.Errorf("invalid time mode '%s'", a.TimeMode)
+	}
+
+	for _, pattern := range append(append([]string{}, a.MilestoneInclude...), a.MilestoneExclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid milestone pattern '%s': %w", pattern, err)
+		}
+	}
+
+	if a.CopyDefaultBranch && a.GiteaDefaultBranch != "" {
+		return errors.New("a Gitea default branch can not be set when copying the GitLab default branch")
+	}
+
+	if a.MaxAPICalls < 0 {
+		return fmt.Errorf("invalid API call budget %d", a.MaxAPICalls)
+	}
+
+	if a.RequestTimeout < 0 {
+		return fmt.Errorf("invalid request timeout %s", a.RequestTimeout)
+	}
+
+	if a.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d", a.Concurrency)
+	}
+
+	switch a.DedupBy {
+	case dedupMarker, dedupTitle, dedupExternalID:
+	default:
+		return fmt.Errorf("invalid dedup strategy '%s'", a.DedupBy)
+	}
+	switch a.MarkerStyle {
+	case markerStyleHTML, markerStyleText:
+	default:
+		return fmt.Errorf("invalid marker style '%s'", a.MarkerStyle)
+	}
+	if err := validateMarkerFormat(a.MarkerFormat, a.MarkerStyle); err != nil {
+		return err
+	}
+
+	if a.DedupSuffix && a.DedupBy != dedupTitle {
+		return errors.New("the dedup suffix requires matching by title")
+	}
+
+	if a.MilestoneCloseDates && !a.ReconcileMilestones {
+		return errors.New("adding milestone closure dates requires reconciling milestones")
+	}
+
+	if a.TwoPass && !a.RewriteRefs {
+		return errors.New("the two pass issue migration requires rewriting issue references")
+	}
+	return nil
+}
+
+// printConfig prints the effective configuration as YAML with redacted tokens.
+// The output can be used as template for a configuration.
+func printConfig(args arguments) error {
+	for _, token := range []*string{&args.GitlabToken, &args.GiteaToken} {
+		if *token != "" {
+			*token = "REDACTED"
+		}
+	}
+
+	data, err := yaml.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// createLogger returns a logger that writes to the output with all secrets
+// redacted.
+func createLogger(output io.Writer, redact redactor) (*log.Logger, error) {
 	cfg, err := log.ConfigForEnv(env.Development)
 	if err != nil {
 		return nil, fmt.Errorf("initializing log config: %w", err)
 	}
 	cfg.JSONOutput = false
 	cfg.CallerInfo = false
+	cfg.Output = redact.writer(output)
 
 	logger, err := log.NewWithConfig(cfg)
 	if err != nil {
@@ -101,13 +330,54 @@ func createLogger() (*log.Logger, error) {
 
 // newMigrator returns a new creator object.
 // It also tests that Gitlab and gitea can be reached.
-func newMigrator(args arguments, logger *log.Logger) (*migrator, error) {
+func newMigrator(args arguments, logger *log.Logger, redact redactor) (*migrator, error) {
 	m := &migrator{
-		args:   args,
-		logger: logger,
+		args:     args,
+		logger:   logger,
+		input:    bufio.NewReader(os.Stdin),
+		redactor: redact,
+
+		apiCalls: &apiCallCounter{
+			next:   http.DefaultTransport,
+			budget: args.MaxAPICalls,
+		},
+
+		giteaUsers:      map[string]bool{},
+		seenTitles:      map[string]int{},
+		fuzzyMilestones: map[string]*gitea.Milestone{},
+	}
+	m.httpClient = &http.Client{
+		Transport: htmlResponseCheck{
+			next: timeoutRetry{
+				next:    m.apiCalls,
+				timeout: args.RequestTimeout,
+				retries: timeoutRetries,
+				backoff: timeoutRetryBackoff,
+			},
+		},
 	}
 
 	var err error
+	m.markerRegex, err = compileMarkerRegex(args.MarkerFormat, args.MarkerStyle)
+	if err != nil {
+		return nil, fmt.Errorf("compiling marker format: %w", err)
+	}
+
+	if args.Webhook != "" {
+		m.runID, err = newRunID()
+		if err != nil {
+			return nil, fmt.Errorf("creating run ID: %w", err)
+		}
+		logger.Info("Sending webhook events", log.String("run_id", m.runID))
+	}
+
+	if args.LabelScheme != "" {
+		m.labelScheme, err = readLabelScheme(args.LabelScheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	m.gitlab, err = m.gitlabClient()
 	if err != nil {
 		return nil, err
@@ -123,38 +393,126 @@ func newMigrator(args arguments, logger *log.Logger) (*migrator, error) {
 
 // gitlabClient returns a new Gitlab client with the given command line parameters.
 func (m *migrator) gitlabClient() (*gitlab.Client, error) {
-	client, err := gitlab.NewClient(m.args.GitlabToken, gitlab.WithBaseURL(m.args.GitlabServer))
+	client, err := gitlab.NewClient(m.args.GitlabToken,
+		gitlab.WithBaseURL(m.args.GitlabServer),
+		gitlab.WithHTTPClient(m.httpClient),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("creating Gitlab client: %w", err)
 	}
 
+	if err = m.checkGitlabVersion(client); err != nil {
+		return nil, err
+	}
+
 	// get the user status to check that the auth and connection works
 	_, _, err = client.Users.CurrentUserStatus()
 	if err != nil {
 		return nil, fmt.Errorf("getting GitLab user status: %w", err)
 	}
 
-	project, _, err := client.Projects.GetProject(m.args.GitlabProject, nil)
+	projectPath, err := gitlabProjectPath(m.args.GitlabServer, m.args.GitlabProject)
+	if err != nil {
+		return nil, err
+	}
+	m.args.GitlabProject = projectPath
+
+	project, _, err := client.Projects.GetProject(projectPath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("getting GitLab project info: %w", err)
 	}
 	m.gitlabProjectID = project.ID
+	m.gitlabDefaultBranch = project.DefaultBranch
 
 	return client, nil
 }
 
+// gitlabProjectPath returns the project path like group/subgroup/project of
+// a project name that can be given as bare path, URL encoded path or as full
+// URL copied from the browser. The path is escaped by the GitLab client.
+func gitlabProjectPath(server, project string) (string, error) {
+	if !strings.Contains(project, "://") {
+		p, err := url.PathUnescape(project)
+		if err != nil {
+			return "", fmt.Errorf("unescaping GitLab project name: %w", err)
+		}
+		return strings.Trim(p, "/"), nil
+	}
+
+	u, err := url.Parse(project)
+	if err != nil {
+		return "", fmt.Errorf("parsing GitLab project URL: %w", err)
+	}
+	p := u.Path
+
+	// remove the path of a GitLab server that is hosted on a subpath
+	if server != "" {
+		serverURL, err := url.Parse(server)
+		if err != nil {
+			return "", fmt.Errorf("parsing GitLab server URL: %w", err)
+		}
+		if serverURL.Host == u.Host {
+			p = strings.TrimPrefix(p, strings.TrimSuffix(serverURL.Path, "/"))
+		}
+	}
+
+	// remove page specific suffixes like /-/issues or .git
+	p, _, _ = strings.Cut(p, "/-/")
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	if p == "" {
+		return "", fmt.Errorf("GitLab project URL '%s' contains no project path", project)
+	}
+	return p, nil
+}
+
+// giteaServerURL returns the normalized Gitea server URL without trailing
+// slash. The URL can contain a subpath for instances that are hosted behind a
+// reverse proxy, an accidentally included API path is removed.
+func giteaServerURL(server string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil {
+		return "", fmt.Errorf("parsing Gitea server URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid Gitea server URL '%s', use http(s)://host[/subpath]", server)
+	}
+
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v1")
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
 // giteaClient returns a new Gitea client with the given command line parameters.
 func (m *migrator) giteaClient() (*gitea.Client, error) {
-	client, err := gitea.NewClient(m.args.GiteaServer, gitea.SetToken(m.args.GiteaToken))
+	server, err := giteaServerURL(m.args.GiteaServer)
 	if err != nil {
-		return nil, fmt.Errorf("creating Gitea client: %w", err)
+		return nil, err
+	}
+	m.args.GiteaServer = server
+
+	// creating the client requests the server version, which does not need
+	// authentication and fails if the base URL or subpath is wrong
+	client, err := gitea.NewClient(server,
+		gitea.SetToken(m.args.GiteaToken),
+		gitea.SetHTTPClient(m.httpClient),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("reaching Gitea API at %s/api/v1 failed, check the server URL and subpath: %w", server, err)
 	}
 
 	// get the user info to check that the auth and connection works
-	_, _, err = client.GetMyUserInfo()
+	user, resp, err := client.GetMyUserInfo()
 	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, fmt.Errorf("authenticating with Gitea failed, check the token: %w", err)
+		}
 		return nil, fmt.Errorf("getting Gitea user info: %w", err)
 	}
+	m.giteaUser = user
+	if m.args.SudoAuthors && !user.IsAdmin {
+		return nil, fmt.Errorf("creating issues as their authors requires a Gitea admin token, %s is no admin", user.UserName)
+	}
 
 	giteaProject := m.args.GiteaProject
 	if giteaProject == "" {
@@ -167,6 +525,10 @@ func (m *migrator) giteaClient() (*gitea.Client, error) {
 	m.giteaOwner = sl[0]
 	m.giteaRepo = sl[1]
 
+	if m.args.SkipRepoCheck {
+		return client, nil
+	}
+
 	repo, _, err := client.GetRepo(m.giteaOwner, m.giteaRepo)
 	if err != nil {
 		return nil, fmt.Errorf("getting Gitea repo info: %w", err)
@@ -178,19 +540,72 @@ func (m *migrator) giteaClient() (*gitea.Client, error) {
 
 // migrateProject migrates all supported aspects of a project.
 func (m *migrator) migrateProject() error {
-	m.logger.Info("Migrating milestones")
-	if err := m.migrateMilestones(); err != nil {
-		return fmt.Errorf("migrating milestones: %w", err)
+	if m.args.MigrateComments != nil {
+		return m.runPhase("migrating comments", m.migrateComments)
+	}
+
+	if m.args.ReconcileMilestones {
+		return m.runPhase("reconciling milestone states", m.reconcileMilestones)
+	}
+
+	if m.args.GiteaDefaultBranch != "" || m.args.CopyDefaultBranch {
+		if err := m.runPhase("setting default branch", m.setDefaultBranch); err != nil {
+			return err
+		}
 	}
 
-	m.logger.Info("Migrating labels")
-	if err := m.migrateLabels(); err != nil {
-		return fmt.Errorf("migrating labels: %w", err)
+	if err := m.runPhase("migrating milestones", m.migrateMilestones); err != nil {
+		return err
 	}
 
-	m.logger.Info("Migrating issues")
-	if err := m.migrateIssues(); err != nil {
-		return fmt.Errorf("migrating issues: %w", err)
+	if m.args.IterationsAsMilestones {
+		if err := m.runPhase("migrating iterations", m.migrateIterations); err != nil {
+			return err
+		}
+	}
+
+	if err := m.runPhase("migrating labels", m.migrateLabels); err != nil {
+		return err
+	}
+
+	if err := m.runPhase(phaseMigratingIssues, m.migrateIssues); err != nil {
+		return err
+	}
+
+	if m.args.MovedIssues {
+		if err := m.runPhase("migrating moved issues", m.migrateMovedIssues); err != nil {
+			return err
+		}
+	}
+
+	if m.args.FillGaps {
+		if err := m.runPhase("filling issue gaps", m.fillIssueGaps); err != nil {
+			return err
+		}
+	}
+
+	if m.args.MapCSV != "" {
+		if err := m.runPhase("writing issue mapping", m.writeIssueMapping); err != nil {
+			return err
+		}
+	}
+
+	if m.args.RewriteRefs {
+		if err := m.runPhase("rewriting issue references", m.rewriteReferences); err != nil {
+			return err
+		}
+	}
+
+	if m.args.PackageNotes {
+		if err := m.runPhase("migrating package notes", m.migratePackageNotes); err != nil {
+			return err
+		}
+	}
+
+	if m.args.LinkCheck {
+		if err := m.runPhase("checking links", m.checkLinks); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -202,38 +617,68 @@ func (m *migrator) migrateMilestones() error {
 		return err
 	}
 
-	state := "active"
+	return m.forEachGitlabMilestone("active", func(milestone *gitlab.Milestone) error {
+		if _, ok := existing[milestone.Title]; ok {
+			return nil
+		}
+		if !m.milestoneSelected(milestone.Title) {
+			m.logger.Debug("Skipping excluded milestone", log.String("title", milestone.Title))
+			return nil
+		}
+		if m.fuzzyMilestone(milestone.Title, existing) != nil {
+			return nil
+		}
+
+		o := gitea.CreateMilestoneOption{
+			Title:       milestone.Title,
+			Description: milestone.Description,
+			Deadline:    (*time.Time)(milestone.DueDate),
+		}
+		err := m.migrateItem("Milestone "+o.Title, func() error {
+			created, _, err := m.gitea.CreateMilestone(m.giteaOwner, m.giteaRepo, o)
+			if err != nil {
+				return err
+			}
+			m.logger.Info("Created milestone", log.String("title", o.Title))
+			m.sendEvent(eventMilestone, int64(milestone.ID), created.ID, eventCreated)
+			return nil
+		})
+		if err != nil {
+			m.sendEvent(eventMilestone, int64(milestone.ID), 0, eventFailed)
+		}
+		return err
+	})
+}
+
+// forEachGitlabMilestone calls the given function for every GitLab milestone
+// of the given state. An empty state lists milestones of all states.
+func (m *migrator) forEachGitlabMilestone(state string, fn func(milestone *gitlab.Milestone) error) error {
 	for page := 1; ; page++ {
 		opt := &gitlab.ListMilestonesOptions{
 			ListOptions: gitlab.ListOptions{
 				Page:    page,
-				PerPage: 100,
+				PerPage: gitlabPageSize,
 			},
-			State: &state,
+		}
+		if state != "" {
+			opt.State = &state
 		}
 
 		gitlabMilestones, _, err := m.gitlab.Milestones.ListMilestones(m.gitlabProjectID, opt, nil)
 		if err != nil {
 			return err
 		}
+		logPage(m, "gitlab milestones", page, gitlabMilestones, func(milestone *gitlab.Milestone) int64 {
+			return int64(milestone.ID)
+		})
 		if len(gitlabMilestones) == 0 {
 			return nil
 		}
 
 		for _, milestone := range gitlabMilestones {
-			if _, ok := existing[milestone.Title]; ok {
-				continue
-			}
-
-			o := gitea.CreateMilestoneOption{
-				Title:       milestone.Title,
-				Description: milestone.Description,
-				Deadline:    (*time.Time)(milestone.DueDate),
-			}
-			if _, _, err = m.gitea.CreateMilestone(m.giteaOwner, m.giteaRepo, o); err != nil {
+			if err = fn(milestone); err != nil {
 				return err
 			}
-			m.logger.Info("Created milestone", log.String("title", o.Title))
 		}
 	}
 }
@@ -245,11 +690,56 @@ func (m *migrator) migrateLabels() error {
 		return err
 	}
 
+	err = m.forEachGitlabLabel(func(label *gitlab.Label) error {
+		name := m.giteaLabelName(label.Name)
+		if _, ok := existing[name]; ok {
+			return nil
+		}
+
+		o := gitea.CreateLabelOption{
+			Name:        name,
+			Description: label.Description,
+			Color:       label.Color,
+		}
+		m.applyLabelScheme(&o)
+		err := m.migrateItem("Label "+o.Name, func() error {
+			var (
+				created *gitea.Label
+				err     error
+			)
+			if m.exclusiveScope(label.Name) != "" {
+				created, err = m.createExclusiveLabel(o)
+			} else {
+				created, err = m.createLabel(o)
+			}
+			if err != nil {
+				return err
+			}
+			m.logger.Info("Created label",
+				log.String("name", o.Name),
+				log.String("color", o.Color),
+			)
+			m.sendEvent(eventLabel, int64(label.ID), created.ID, eventCreated)
+			return nil
+		})
+		if err != nil {
+			m.sendEvent(eventLabel, int64(label.ID), 0, eventFailed)
+		}
+		return err
+	})
+	if err != nil || m.labelScheme == nil {
+		return err
+	}
+	return m.migrateSchemeLabels()
+}
+
+// forEachGitlabLabel calls the given function for every GitLab label.
+func (m *migrator) forEachGitlabLabel(fn func(label *gitlab.Label) error) error {
 	for page := 1; ; page++ {
 		opt := &gitlab.ListLabelsOptions{
 			ListOptions: gitlab.ListOptions{
 				Page:    page,
-				PerPage: 100,
+				PerPage: gitlabPageSize,
 			},
 		}
 
@@ -257,34 +747,39 @@ func (m *migrator) migrateLabels() error {
 		if err != nil {
 			return err
 		}
+		logPage(m, "gitlab labels", page, gitlabLabels, func(label *gitlab.Label) int64 {
+			return int64(label.ID)
+		})
 		if len(gitlabLabels) == 0 {
 			return nil
 		}
 
 		for _, label := range gitlabLabels {
-			if _, ok := existing[label.Name]; ok {
-				continue
-			}
-
-			o := gitea.CreateLabelOption{
-				Name:        label.Name,
-				Description: label.Description,
-				Color:       label.Color,
-			}
-			if _, _, err = m.gitea.CreateLabel(m.giteaOwner, m.giteaRepo, o); err != nil {
+			if err = fn(label); err != nil {
 				return err
 			}
-			m.logger.Info("Created label",
-				log.String("name", o.Name),
-				log.String("color", o.Color),
-			)
 		}
 	}
 }
 
 // migrateIssues migrates all open issues.
 func (m *migrator) migrateIssues() error {
-	giteaIssues, err := m.giteaIssues()
+	if m.args.ResumeFromIID > 0 {
+		if m.args.IssueOrder == issueOrderUpdated {
+			m.logger.Warn("Issues are not listed in ascending IID order, lower IIDs are still skipped",
+				log.Int("resume_from_iid", m.args.ResumeFromIID))
+		} else {
+			m.logger.Info("Resuming from issue", log.Int("iid", m.args.ResumeFromIID))
+		}
+	}
+
+	if m.args.DedupSuffix {
+		if err := m.scanIssueTitles(); err != nil {
+			return fmt.Errorf("scanning issue titles: %w", err)
+		}
+	}
+
+	giteaIssues, err := m.existingIssues()
 	if err != nil {
 		return err
 	}
@@ -296,75 +791,281 @@ func (m *migrator) migrateIssues() error {
 	if err != nil {
 		return err
 	}
+	if m.args.TimeMode == timeModeDetailed {
+		if err = m.detectTimeTracking(); err != nil {
+			return err
+		}
+	}
+
+	if m.args.TwoPass {
+		m.logger.Info("Creating issue skeletons")
+		if err = m.createIssueSkeletons(giteaIssues); err != nil {
+			return fmt.Errorf("creating issue skeletons: %w", err)
+		}
+		if giteaIssues, err = m.existingIssues(); err != nil {
+			return err
+		}
+	}
+
+	err = m.forEachGitlabIssue("opened", func(issue *gitlab.Issue) error {
+		m.currentIssueIID = issue.IID
+		err := m.migrateItem(fmt.Sprintf("Issue %d", issue.IID), func() error {
+			return m.migrateIssue(issue, giteaMilestones, giteaLabels, giteaIssues)
+		})
+		if err != nil {
+			m.sendEvent(eventIssue, int64(issue.IID), 0, eventFailed)
+		}
+		return err
+	})
+	m.reportBodyMismatches()
+	return err
+}
+
+// forEachGitlabIssue calls the given function for every GitLab issue of the
+// given state in the configured listing order.
+func (m *migrator) forEachGitlabIssue(state string, fn func(issue *gitlab.Issue) error) error {
+	if m.args.ResumeFromIID > 0 {
+		next := fn
+		fn = func(issue *gitlab.Issue) error {
+			if issue.IID < m.args.ResumeFromIID {
+				return nil
+			}
+			return next(issue)
+		}
+	}
+
+	return m.listGitlabIssues(state, fn)
+}
+
+// listGitlabIssues calls the given function for every GitLab issue of the
+// given state in the configured listing order, including the issues before
+// the resume IID.
+func (m *migrator) listGitlabIssues(state string, fn func(issue *gitlab.Issue) error) error {
+	if m.args.IssueOrder == issueOrderUpdated {
+		return m.forEachGitlabIssueByUpdate(state, fn)
+	}
 
-	state := "opened"
 	for page := 1; ; page++ {
 		opt := &gitlab.ListProjectIssuesOptions{
 			ListOptions: gitlab.ListOptions{
 				Page:    page,
-				PerPage: 100,
+				PerPage: gitlabPageSize,
 			},
 			State: &state,
 		}
+		if m.args.ResumeFromIID > 0 {
+			// IIDs are assigned in creation order
+			orderBy := "created_at"
+			sort := "asc"
+			opt.OrderBy = &orderBy
+			opt.Sort = &sort
+		}
 
 		gitlabIssues, _, err := m.gitlab.Issues.ListProjectIssues(m.gitlabProjectID, opt, nil)
 		if err != nil {
 			return err
 		}
+		logPage(m, "gitlab issues", page, gitlabIssues, func(issue *gitlab.Issue) int64 {
+			return int64(issue.IID)
+		})
 		if len(gitlabIssues) == 0 {
 			return nil
 		}
 
 		for _, issue := range gitlabIssues {
-			if err = m.migrateIssue(issue, giteaMilestones, giteaLabels, giteaIssues); err != nil {
+			if err = fn(issue); err != nil {
 				return err
 			}
 		}
 	}
 }
 
-// migrateIssue migrates a single issue.
+// forEachGitlabIssueByUpdate calls the given function for every GitLab issue
+// of the given state ordered by the update time ascending. Instead of a page
+// offset it uses the update time of the last returned issue as cursor for the
+// next request, which keeps the listing window stable while new issues get
+// created. Issues returned multiple times are only processed once.
+func (m *migrator) forEachGitlabIssueByUpdate(state string, fn func(issue *gitlab.Issue) error) error {
+	orderBy := "updated_at"
+	sort := "asc"
+	seen := map[int]struct{}{}
+	var cursor *time.Time
+
+	for page := 1; ; {
+		opt := &gitlab.ListProjectIssuesOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    page,
+				PerPage: gitlabPageSize,
+			},
+			State:        &state,
+			OrderBy:      &orderBy,
+			Sort:         &sort,
+			UpdatedAfter: cursor,
+		}
+
+		gitlabIssues, _, err := m.gitlab.Issues.ListProjectIssues(m.gitlabProjectID, opt, nil)
+		if err != nil {
+			return err
+		}
+		logPage(m, "gitlab issues", page, gitlabIssues, func(issue *gitlab.Issue) int64 {
+			return int64(issue.IID)
+		})
+
+		var processed int
+		for _, issue := range gitlabIssues {
+			if _, ok := seen[issue.ID]; ok {
+				continue
+			}
+			seen[issue.ID] = struct{}{}
+			processed++
+
+			if err = fn(issue); err != nil {
+				return err
+			}
+		}
+
+		// a partial page without new issues marks the end of the listing
+		if len(gitlabIssues) < gitlabPageSize && processed == 0 {
+			return nil
+		}
+
+		// the update time filter is inclusive, if all issues of a full page
+		// share the cursor time the next page of the same window is needed
+		last := gitlabIssues[len(gitlabIssues)-1].UpdatedAt
+		if last == nil || (cursor != nil && last.Equal(*cursor)) {
+			page++
+			continue
+		}
+		cursor = last
+		page = 1
+	}
+}
+
+// migrateIssue migrates a single issue. The given Gitea issues are keyed by
+// the configured deduplication strategy.
 func (m *migrator) migrateIssue(issue *gitlab.Issue, giteaMilestones map[string]*gitea.Milestone,
 	giteaLabels map[string]*gitea.Label, giteaIssues map[string]*gitea.Issue) error {
+	source := issue
+	if m.args.CommentsInBody {
+		section, err := m.commentsSection(issue)
+		if err != nil {
+			return err
+		}
+		withComments := *issue
+		withComments.Description += section
+		issue = &withComments
+	}
+
 	o := gitea.CreateIssueOption{
-		Title:    issue.Title,
-		Body:     issue.Description,
+		Title:    m.issueTitle(issue),
+		Body:     m.rewritePassTwoBody(m.normalizeEmoji(m.issueBody(issue))),
 		Deadline: (*time.Time)(issue.DueDate),
+		Closed:   issue.State == "closed",
 	}
 
 	if issue.Milestone != nil {
-		milestone, ok := giteaMilestones[issue.Milestone.Title]
-		if ok {
-			o.Milestone = milestone.ID
+		o.Milestone = m.issueMilestone(issue, giteaMilestones)
+	}
+
+	if issue.Milestone == nil && issue.Iteration != nil && m.args.IterationsAsMilestones {
+		title := iterationTitle(issue.Iteration.Title, issue.Iteration.StartDate, issue.Iteration.DueDate)
+		if milestone, ok := giteaMilestones[title]; ok {
+			if !m.droppedClosedMilestone(issue, milestone) {
+				o.Milestone = milestone.ID
+			}
 		} else {
-			m.logger.Error("Unknown milestone", log.String("milestone", issue.Milestone.Title))
+			m.logger.Error("Unknown iteration milestone", log.String("milestone", title))
 		}
 	}
 
-	for _, l := range issue.Labels {
-		label, ok := giteaLabels[l]
-		if ok {
-			o.Labels = append(o.Labels, label.ID)
-		} else {
-			m.logger.Error("Unknown label", log.String("label", l))
+	o.Labels = m.giteaIssueLabels(issue, giteaLabels)
+
+	sudo, err := m.sudoAuthor(issue, &o)
+	if err != nil {
+		return err
+	}
+
+	giteaIssue, err := m.createOrUpdateIssue(issue, o, sudo, giteaIssues)
+	if err != nil {
+		return err
+	}
+	if m.args.VerifyBodies {
+		if err := m.verifyIssueBody(source, giteaIssue.Index); err != nil {
+			return err
 		}
 	}
+	return m.migrateIssueDetails(issue, giteaIssue.Index)
+}
 
-	existing, ok := giteaIssues[issue.Title]
+// createOrUpdateIssue creates the Gitea issue for the GitLab issue or updates
+// the matching existing one and returns it. The given Gitea issues are keyed
+// by the configured deduplication strategy.
+func (m *migrator) createOrUpdateIssue(issue *gitlab.Issue, o gitea.CreateIssueOption, sudo string,
+	giteaIssues map[string]*gitea.Issue) (*gitea.Issue, error) {
+	existing, ok := giteaIssues[m.gitlabIssueKey(issue)]
 	if !ok {
-		if _, _, err := m.gitea.CreateIssue(m.giteaOwner, m.giteaRepo, o); err != nil {
-			return err
+		created, err := m.createIssue(o, sudo)
+		if created != nil {
+			// a retry of a failed later step must update the created issue
+			giteaIssues[m.gitlabIssueKey(issue)] = created
+		}
+		if err != nil {
+			return nil, err
 		}
 		m.logger.Info("Created issue", log.String("title", o.Title))
-		return nil
+		m.sendEvent(eventIssue, int64(issue.IID), created.Index, eventCreated)
+		m.addIssueMapping(issue, created)
+		return created, nil
 	}
 
+	if err := m.updateIssue(existing, o); err != nil {
+		return nil, err
+	}
+	m.logger.Info("Updated issue", log.String("title", o.Title))
+	m.sendEvent(eventIssue, int64(issue.IID), existing.Index, eventUpdated)
+	m.addIssueMapping(issue, existing)
+	return existing, nil
+}
+
+// issueMilestone returns the ID of the Gitea milestone to assign to the issue,
+// or 0 if the GitLab milestone is excluded or can not be assigned. Excluded
+// milestones are not assigned even if they exist in Gitea.
+func (m *migrator) issueMilestone(issue *gitlab.Issue, giteaMilestones map[string]*gitea.Milestone) int64 {
+	title := issue.Milestone.Title
+	if !m.milestoneSelected(title) {
+		m.logger.Info("Not assigning excluded milestone",
+			log.String("title", issue.Title),
+			log.String("milestone", title),
+		)
+		return 0
+	}
+
+	milestone, ok := giteaMilestones[title]
+	if !ok {
+		milestone = m.fuzzyMilestone(title, giteaMilestones)
+	}
+	if milestone == nil {
+		m.logger.Error("Unknown milestone", log.String("milestone", title))
+		return 0
+	}
+	if m.droppedClosedMilestone(issue, milestone) {
+		return 0
+	}
+	return milestone.ID
+}
+
+// updateIssue updates an existing Gitea issue with the given options.
+func (m *migrator) updateIssue(existing *gitea.Issue, o gitea.CreateIssueOption) error {
 	editOptions := gitea.EditIssueOption{
 		Title:     o.Title,
 		Body:      &o.Body,
 		Milestone: &o.Milestone,
 		Deadline:  o.Deadline,
 	}
+	if o.Closed {
+		state := gitea.StateClosed
+		editOptions.State = &state
+	}
 	if _, _, err := m.gitea.EditIssue(m.giteaOwner, m.giteaRepo, existing.Index, editOptions); err != nil {
 		return err
 	}
@@ -374,46 +1075,116 @@ func (m *migrator) migrateIssue(issue *gitlab.Issue, giteaMilestones map[string]
 	if _, _, err := m.gitea.ReplaceIssueLabels(m.giteaOwner, m.giteaRepo, existing.Index, labelOptions); err != nil {
 		return err
 	}
-
-	m.logger.Info("Updated issue", log.String("title", o.Title))
 	return nil
 }
 
-// giteaMilestones returns a map of all gitea milestones.
-func (m *migrator) giteaMilestones() (map[string]*gitea.Milestone, error) {
-	opt := gitea.ListMilestoneOption{
-		State: "all",
+// migrateIssueDetails migrates the data of a GitLab issue that is not part of
+// the Gitea issue itself into the Gitea issue with the given index.
+func (m *migrator) migrateIssueDetails(issue *gitlab.Issue, index int64) error {
+	if m.args.TimeMode == timeModeDetailed {
+		if err := m.migrateSpentTime(issue, index); err != nil {
+			return fmt.Errorf("migrating spent time: %w", err)
+		}
 	}
-	giteaMilestones, _, err := m.gitea.ListRepoMilestones(m.giteaOwner, m.giteaRepo, opt)
-	if err != nil {
-		return nil, err
+	return nil
+}
+
+// giteaIssueLabels returns the IDs of the Gitea labels matching the labels of
+// the GitLab issue. The order of the GitLab labels is preserved, as some teams
+// treat the first label as the primary one.
+func (m *migrator) giteaIssueLabels(issue *gitlab.Issue, giteaLabels map[string]*gitea.Label) []int64 {
+	var ids []int64
+	scopes := map[string]struct{}{}
+	for _, l := range issue.Labels {
+		if scope := m.exclusiveScope(l); scope != "" {
+			if _, ok := scopes[scope]; ok {
+				m.logger.Warn("Skipping label of already applied exclusive scope", log.String("label", l))
+				continue
+			}
+			scopes[scope] = struct{}{}
+		}
+
+		label, ok := giteaLabels[m.giteaLabelName(l)]
+		if ok {
+			ids = append(ids, label.ID)
+		} else {
+			m.logger.Error("Unknown label", log.String("label", l))
+		}
 	}
 
+	return ids
+}
+
+// giteaMilestones returns a map of all gitea milestones.
+func (m *migrator) giteaMilestones() (map[string]*gitea.Milestone, error) {
 	milestones := map[string]*gitea.Milestone{}
-	for _, milestone := range giteaMilestones {
-		milestones[milestone.Title] = milestone
+	for page := 1; ; page++ {
+		opt := gitea.ListMilestoneOption{
+			ListOptions: gitea.ListOptions{
+				Page: page,
+			},
+			State: "all",
+		}
+		giteaMilestones, _, err := m.gitea.ListRepoMilestones(m.giteaOwner, m.giteaRepo, opt)
+		if err != nil {
+			return nil, err
+		}
+		logPage(m, "gitea milestones", page, giteaMilestones, func(milestone *gitea.Milestone) int64 {
+			return milestone.ID
+		})
+		if len(giteaMilestones) == 0 {
+			return milestones, nil
+		}
+
+		for _, milestone := range giteaMilestones {
+			milestones[milestone.Title] = milestone
+		}
 	}
-	return milestones, nil
 }
 
-// giteaMilestones returns a map of all gitea labels.
+// giteaLabels returns a map of all gitea labels.
 func (m *migrator) giteaLabels() (map[string]*gitea.Label, error) {
-	opt := gitea.ListLabelsOptions{}
-	giteaLabels, _, err := m.gitea.ListRepoLabels(m.giteaOwner, m.giteaRepo, opt)
+	labels := map[string]*gitea.Label{}
+	for page := 1; ; page++ {
+		opt := gitea.ListLabelsOptions{
+			ListOptions: gitea.ListOptions{
+				Page: page,
+			},
+		}
+		giteaLabels, _, err := m.gitea.ListRepoLabels(m.giteaOwner, m.giteaRepo, opt)
+		if err != nil {
+			return nil, err
+		}
+		logPage(m, "gitea labels", page, giteaLabels, func(label *gitea.Label) int64 {
+			return label.ID
+		})
+		if len(giteaLabels) == 0 {
+			return labels, nil
+		}
+
+		for _, label := range giteaLabels {
+			labels[label.Name] = label
+		}
+	}
+}
+
+// giteaIssues returns a map of all gitea issues by title.
+func (m *migrator) giteaIssues() (map[string]*gitea.Issue, error) {
+	giteaIssues, err := m.listGiteaIssues()
 	if err != nil {
 		return nil, err
 	}
 
-	labels := map[string]*gitea.Label{}
-	for _, label := range giteaLabels {
-		labels[label.Name] = label
+	issues := make(map[string]*gitea.Issue, len(giteaIssues))
+	for _, issue := range giteaIssues {
+		issues[issue.Title] = issue
 	}
-	return labels, nil
+	return issues, nil
 }
 
-// giteaMilestones returns a map of all gitea issues.
-func (m *migrator) giteaIssues() (map[string]*gitea.Issue, error) {
-	issues := map[string]*gitea.Issue{}
+// listGiteaIssues returns all gitea issues.
+func (m *migrator) listGiteaIssues() ([]*gitea.Issue, error) {
+	var issues []*gitea.Issue
 	for page := 1; ; page++ {
 		opt := gitea.ListIssueOption{
 			ListOptions: gitea.ListOptions{
@@ -425,12 +1196,33 @@ func (m *migrator) giteaIssues() (map[string]*gitea.Issue, error) {
 		if err != nil {
 			return nil, err
 		}
+		logPage(m, "gitea issues", page, giteaIssues, func(issue *gitea.Issue) int64 {
+			return issue.Index
+		})
 		if len(giteaIssues) == 0 {
 			return issues, nil
 		}
+		issues = append(issues, giteaIssues...)
+	}
+}
 
-		for _, issue := range giteaIssues {
-			issues[issue.Title] = issue
-		}
+// logPage logs the page number, item count and first and last item ID of a
+// listed page if paging debugging is enabled.
+func logPage[T any](m *migrator, listing string, page int, items []T, id func(T) int64) {
+	if !m.args.DebugPaging {
+		return
+	}
+
+	var first, last int64
+	if len(items) > 0 {
+		first = id(items[0])
+		last = id(items[len(items)-1])
 	}
+	m.logger.Debug("Listed page",
+		log.String("listing", listing),
+		log.Int("page", page),
+		log.Int("items", len(items)),
+		log.Int64("first_id", first),
+		log.Int64("last_id", last),
+	)
 }
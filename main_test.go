@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMigrator returns a migrator without API clients that logs to the test.
+func newTestMigrator(t *testing.T, args arguments) *migrator {
+	t.Helper()
+	return &migrator{
+		args:            args,
+		logger:          log.NewTestLogger(t),
+		giteaUsers:      map[string]bool{},
+		seenTitles:      map[string]int{},
+		fuzzyMilestones: map[string]*gitea.Milestone{},
+	}
+}
+
+func TestGitlabProjectPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   string
+		project  string
+		expected string
+	}{
+		{"bare path", "https://gitlab.com/", "group/project", "group/project"},
+		{"subgroup path", "https://gitlab.com/", "group/subgroup/project", "group/subgroup/project"},
+		{"encoded path", "https://gitlab.com/", "group%2Fsubgroup%2Fproject", "group/subgroup/project"},
+		{"full URL", "https://gitlab.com/", "https://gitlab.com/group/project", "group/project"},
+		{"subgroup URL", "https://gitlab.com/", "https://gitlab.com/group/subgroup/project", "group/subgroup/project"},
+		{"issues suffix", "https://gitlab.com/", "https://gitlab.com/group/project/-/issues", "group/project"},
+		{"git suffix", "https://gitlab.com/", "https://gitlab.com/group/project.git", "group/project"},
+		{"server subpath", "https://example.com/gitlab/", "https://example.com/gitlab/group/project/-/issues/1", "group/project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := gitlabProjectPath(tt.server, tt.project)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+}
+
+func TestGitlabProjectPathInvalid(t *testing.T) {
+	_, err := gitlabProjectPath("https://gitlab.com/", "https://gitlab.com/")
+	assert.Error(t, err)
+}
+
+func TestValidateTwoPassRequiresRewriteRefs(t *testing.T) {
+	args := arguments{
+		IssueOrder:   issueOrderCreated,
+		TimeMode:     timeModeNone,
+		DedupBy:      dedupMarker,
+		MarkerFormat: "gitlab2gitea:{project}#{iid}",
+		MarkerStyle:  markerStyleHTML,
+		Concurrency:  1,
+		TwoPass:      true,
+	}
+	assert.ErrorContains(t, args.validate(), "requires rewriting issue references")
+
+	args.RewriteRefs = true
+	assert.NoError(t, args.validate())
+}
+
+func TestValidateMilestoneCloseDatesRequiresReconcile(t *testing.T) {
+	args := arguments{
+		IssueOrder:          issueOrderCreated,
+		TimeMode:            timeModeNone,
+		DedupBy:             dedupMarker,
+		MarkerFormat:        defaultMarkerFormat,
+		MarkerStyle:         markerStyleHTML,
+		Concurrency:         1,
+		MilestoneCloseDates: true,
+	}
+	assert.ErrorContains(t, args.validate(), "requires reconciling milestones")
+
+	args.ReconcileMilestones = true
+	assert.NoError(t, args.validate())
+}
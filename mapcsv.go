@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// issueMappingHeader is the header line of the issue mapping CSV file.
+var issueMappingHeader = []string{"gitlab_iid", "gitlab_url", "gitea_index", "gitea_url"}
+
+// issueMapping maps a migrated GitLab issue to its Gitea issue.
+type issueMapping struct {
+	gitlabIID  int
+	gitlabURL  string
+	giteaIndex int64
+	giteaURL   string
+}
+
+// addIssueMapping records the Gitea issue that a GitLab issue was migrated to
+// for the mapping CSV file.
+func (m *migrator) addIssueMapping(issue *gitlab.Issue, giteaIssue *gitea.Issue) {
+	if m.args.MapCSV == "" {
+		return
+	}
+	m.issueMappings = append(m.issueMappings, issueMapping{
+		gitlabIID:  issue.IID,
+		gitlabURL:  issue.WebURL,
+		giteaIndex: giteaIssue.Index,
+		giteaURL:   giteaIssue.HTMLURL,
+	})
+}
+
+// writeIssueMapping writes the mapping of all migrated GitLab issues to their
+// Gitea issues as CSV file, ordered by GitLab IID.
+func (m *migrator) writeIssueMapping() error {
+	slices.SortFunc(m.issueMappings, func(a, b issueMapping) int {
+		return a.gitlabIID - b.gitlabIID
+	})
+
+	f, err := os.Create(m.args.MapCSV)
+	if err != nil {
+		return fmt.Errorf("creating issue mapping file: %w", err)
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write(issueMappingHeader)
+	for _, mapping := range m.issueMappings {
+		_ = w.Write([]string{
+			strconv.Itoa(mapping.gitlabIID),
+			mapping.gitlabURL,
+			strconv.FormatInt(mapping.giteaIndex, 10),
+			mapping.giteaURL,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing issue mapping file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing issue mapping file: %w", err)
+	}
+
+	m.logger.Info("Wrote issue mapping", log.String("file", m.args.MapCSV), log.Int("issues", len(m.issueMappings)))
+	return nil
+}
+
+// readIssueMapping reads an issue mapping CSV file that was written by
+// writeIssueMapping.
+func readIssueMapping(fileName string) ([]issueMapping, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("opening issue mapping file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading issue mapping file: %w", err)
+	}
+	if len(records) == 0 || !slices.Equal(records[0], issueMappingHeader) {
+		return nil, fmt.Errorf("issue mapping file %s has no valid header", fileName)
+	}
+
+	mappings := make([]issueMapping, 0, len(records)-1)
+	for i, record := range records[1:] {
+		iid, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitLab IID in line %d: %w", i+2, err)
+		}
+		index, err := strconv.ParseInt(record[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Gitea index in line %d: %w", i+2, err)
+		}
+		mappings = append(mappings, issueMapping{
+			gitlabIID:  iid,
+			gitlabURL:  record[1],
+			giteaIndex: index,
+			giteaURL:   record[3],
+		})
+	}
+	return mappings, nil
+}
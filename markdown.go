@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+)
+
+// replaceOutsideCode calls replace for all parts of the markdown text that are
+// not inside fenced code blocks or inline code spans and returns the text with
+// the replaced parts.
+func replaceOutsideCode(text string, replace func(string) string) string {
+	lines := strings.Split(text, "\n")
+	var inFence bool
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		// parts with an odd index are inside inline code spans, the rest of
+		// the line after an unclosed backtick is left untouched as well
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = replace(parts[j])
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+
+	return strings.Join(lines, "\n")
+}
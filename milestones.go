@@ -0,0 +1,237 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+const closedMilestonePrefix = "Closed: "
+
+// closedMilestoneRegex matches the closure date line at the end of a
+// milestone description.
+var closedMilestoneRegex = regexp.MustCompile(`(?:^|\n)` + closedMilestonePrefix + `\d{4}-\d{2}-\d{2}$`)
+
+// reconcileMilestones sets the open or closed state of all existing Gitea
+// milestones to the state of the matching GitLab milestone. It does not
+// create any milestones and does not touch issues or labels.
+func (m *migrator) reconcileMilestones() error {
+	existing, err := m.giteaMilestones()
+	if err != nil {
+		return err
+	}
+
+	var changed int
+	err = m.forEachGitlabMilestone("", func(milestone *gitlab.Milestone) error {
+		giteaMilestone, ok := existing[milestone.Title]
+		if !ok {
+			return nil
+		}
+
+		state := giteaMilestoneState(milestone)
+		description := giteaMilestone.Description
+		if m.args.MilestoneCloseDates && state == gitea.StateClosed {
+			description = closedMilestoneDescription(description, milestone)
+		}
+		if giteaMilestone.State == state && giteaMilestone.Description == description {
+			return nil
+		}
+
+		return m.migrateItem("Milestone "+milestone.Title, func() error {
+			o := gitea.EditMilestoneOption{
+				Title:       giteaMilestone.Title,
+				Description: &description,
+				State:       &state,
+			}
+			if _, _, err := m.gitea.EditMilestone(m.giteaOwner, m.giteaRepo, giteaMilestone.ID, o); err != nil {
+				return err
+			}
+
+			if giteaMilestone.State == state {
+				m.logger.Info("Added milestone closure date", log.String("title", milestone.Title))
+				return nil
+			}
+			changed++
+			m.logger.Info("Changed milestone state",
+				log.String("title", milestone.Title),
+				log.String("state", string(state)),
+			)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Reconciled milestone states", log.Int("changed", changed))
+	return nil
+}
+
+// closedMilestoneDescription returns the description with the closure date of
+// the GitLab milestone appended, as Gitea does not allow to set the closure
+// date. GitLab does not expose the closure date, the last update date of a
+// closed milestone is used instead. A description that already ends with a
+// closure date line is returned unchanged.
+func closedMilestoneDescription(description string, milestone *gitlab.Milestone) string {
+	if milestone.UpdatedAt == nil || closedMilestoneRegex.MatchString(strings.TrimRight(description, " \r\n")) {
+		return description
+	}
+
+	closed := closedMilestonePrefix + milestone.UpdatedAt.Format(time.DateOnly)
+	if description == "" {
+		return closed
+	}
+	return description + "\n\n" + closed
+}
+
+// giteaMilestoneState returns the Gitea state matching the state of the GitLab milestone.
+func giteaMilestoneState(milestone *gitlab.Milestone) gitea.StateType {
+	if milestone.State == "closed" {
+		return gitea.StateClosed
+	}
+	return gitea.StateOpen
+}
+
+// droppedClosedMilestone returns whether the assignment of the milestone to
+// the issue is dropped because the milestone is closed and only open
+// milestones are assigned.
+func (m *migrator) droppedClosedMilestone(issue *gitlab.Issue, milestone *gitea.Milestone) bool {
+	if !m.args.OpenMilestonesOnly || milestone.State != gitea.StateClosed {
+		return false
+	}
+	m.logger.Info("Not assigning closed milestone",
+		log.String("title", issue.Title),
+		log.String("milestone", milestone.Title),
+	)
+	return true
+}
+
+// milestoneSelected returns whether the milestone title matches the include
+// patterns and none of the exclude patterns. Exclude patterns take precedence,
+// without include patterns all milestones are included.
+func (m *migrator) milestoneSelected(title string) bool {
+	for _, pattern := range m.args.MilestoneExclude {
+		if ok, _ := path.Match(pattern, title); ok {
+			return false
+		}
+	}
+
+	if len(m.args.MilestoneInclude) == 0 {
+		return true
+	}
+	for _, pattern := range m.args.MilestoneInclude {
+		if ok, _ := path.Match(pattern, title); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMilestone returns the existing Gitea milestone that best matches the
+// GitLab milestone title if fuzzy matching is enabled. Titles are compared as
+// lowercase words without a version prefix, like "Release v1.2" and
+// "release-1.2". If no title has equal words, the shortest title whose words
+// contain the words of the other one is chosen, as long as both have the
+// same version numbers.
+func (m *migrator) fuzzyMilestone(title string, giteaMilestones map[string]*gitea.Milestone) *gitea.Milestone {
+	if !m.args.MilestoneFuzzy {
+		return nil
+	}
+	if milestone, ok := m.fuzzyMilestones[title]; ok {
+		return milestone
+	}
+
+	words := milestoneWords(title)
+	var best *gitea.Milestone
+	var bestExact bool
+	for _, milestone := range giteaMilestones {
+		match, exact := matchMilestoneWords(words, milestoneWords(milestone.Title))
+		if !match {
+			continue
+		}
+
+		if best == nil || (exact && !bestExact) || (exact == bestExact && betterMilestoneMatch(milestone, best)) {
+			best = milestone
+			bestExact = exact
+		}
+	}
+
+	m.fuzzyMilestones[title] = best
+	if best != nil {
+		m.logger.Info("Matched milestone",
+			log.String("gitlab", title),
+			log.String("gitea", best.Title),
+		)
+	}
+	return best
+}
+
+// matchMilestoneWords returns whether the words of two milestone titles match
+// and whether they match exactly. Titles match if the words of one contain the
+// words of the other one in sequence and their version numbers are equal, so
+// that 1.1 does not match 1.10.
+func matchMilestoneWords(a, b []string) (match, exact bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return false, false
+	}
+	if slices.Equal(a, b) {
+		return true, true
+	}
+	if !slices.Equal(numericWords(a), numericWords(b)) {
+		return false, false
+	}
+	return containsWords(a, b) || containsWords(b, a), false
+}
+
+// containsWords returns whether words contains sub as contiguous sequence.
+func containsWords(words, sub []string) bool {
+	for i := 0; i+len(sub) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(sub)], sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// numericWords returns the words that consist of digits only.
+func numericWords(words []string) []string {
+	var numeric []string
+	for _, word := range words {
+		if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			numeric = append(numeric, word)
+		}
+	}
+	return numeric
+}
+
+// betterMilestoneMatch returns whether the milestone is a better fuzzy match
+// than the current best one, preferring shorter titles and deciding ties
+// alphabetically to keep the choice deterministic.
+func betterMilestoneMatch(milestone, best *gitea.Milestone) bool {
+	if len(milestone.Title) != len(best.Title) {
+		return len(milestone.Title) < len(best.Title)
+	}
+	return milestone.Title < best.Title
+}
+
+// milestoneWords returns the lowercase words of a milestone title, split at
+// all characters except letters and digits. The v of version numbers like
+// v1.2 is removed.
+func milestoneWords(title string) []string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if len(word) > 1 && word[0] == 'v' && unicode.IsDigit(rune(word[1])) {
+			words[i] = word[1:]
+		}
+	}
+	return words
+}
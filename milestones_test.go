@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestIssueMilestoneExcluded(t *testing.T) {
+	m := newTestMigrator(t, arguments{MilestoneExclude: []string{"old-*"}})
+	giteaMilestones := map[string]*gitea.Milestone{
+		"old-1": {ID: 1, Title: "old-1"},
+		"v2":    {ID: 2, Title: "v2"},
+	}
+
+	issue := &gitlab.Issue{Milestone: &gitlab.Milestone{Title: "old-1"}}
+	assert.Equal(t, int64(0), m.issueMilestone(issue, giteaMilestones))
+
+	issue = &gitlab.Issue{Milestone: &gitlab.Milestone{Title: "v2"}}
+	assert.Equal(t, int64(2), m.issueMilestone(issue, giteaMilestones))
+}
+
+func TestFuzzyMilestone(t *testing.T) {
+	giteaMilestones := map[string]*gitea.Milestone{
+		"1.10":               {ID: 1, Title: "1.10"},
+		"v10":                {ID: 2, Title: "v10"},
+		"release-1.2":        {ID: 3, Title: "release-1.2"},
+		"Sprint 5 (2024 Q3)": {ID: 4, Title: "Sprint 5 (2024 Q3)"},
+		"Backlog":            {ID: 5, Title: "Backlog"},
+	}
+
+	tests := []struct {
+		title    string
+		expected int64
+	}{
+		{"1.1", 0},
+		{"v1", 0},
+		{"1.10", 1},
+		{"v1.10", 1},
+		{"V10", 2},
+		{"Release v1.2", 3},
+		{"release 1.2.1", 0},
+		{"backlog", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			m := newTestMigrator(t, arguments{MilestoneFuzzy: true})
+			milestone := m.fuzzyMilestone(tt.title, giteaMilestones)
+			if tt.expected == 0 {
+				assert.Nil(t, milestone)
+				return
+			}
+			if assert.NotNil(t, milestone) {
+				assert.Equal(t, tt.expected, milestone.ID)
+			}
+		})
+	}
+}
+
+func TestClosedMilestoneDescription(t *testing.T) {
+	updated := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	milestone := &gitlab.Milestone{State: "closed", UpdatedAt: &updated}
+
+	tests := []struct {
+		name        string
+		description string
+		expected    string
+	}{
+		{"empty", "", "Closed: 2024-03-05"},
+		{"appended", "Release", "Release\n\nClosed: 2024-03-05"},
+		{"already added", "Release\n\nClosed: 2024-03-05", "Release\n\nClosed: 2024-03-05"},
+		{"trailing newline", "Closed: 2024-03-05\n", "Closed: 2024-03-05\n"},
+		{"text mentions prefix", "Closed: all bugs", "Closed: all bugs\n\nClosed: 2024-03-05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, closedMilestoneDescription(tt.description, milestone))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	movedLabelName  = "moved"
+	movedLabelColor = "#9e9e9e"
+)
+
+// migrateMovedIssues migrates all closed GitLab issues that were moved to
+// another project. They are created as closed Gitea issues with a moved label
+// and a reference to the destination where the discussion continued.
+func (m *migrator) migrateMovedIssues() error {
+	giteaIssues, err := m.existingIssues()
+	if err != nil {
+		return err
+	}
+	giteaMilestones, err := m.giteaMilestones()
+	if err != nil {
+		return err
+	}
+	giteaLabels, err := m.giteaLabels()
+	if err != nil {
+		return err
+	}
+	o := gitea.CreateLabelOption{
+		Name:        movedLabelName,
+		Description: "Issue was moved to another GitLab project",
+		Color:       movedLabelColor,
+	}
+	if err = m.ensureLabel(giteaLabels, o); err != nil {
+		return err
+	}
+
+	return m.forEachGitlabIssue("closed", func(issue *gitlab.Issue) error {
+		if issue.MovedToID == 0 {
+			return nil
+		}
+
+		err := m.migrateItem(fmt.Sprintf("Issue %d", issue.IID), func() error {
+			moved := *issue
+			moved.Description = fmt.Sprintf("%s\n\n---\n_Moved in GitLab to %s._", issue.Description, m.movedIssueReference(issue))
+			moved.Labels = append(append(gitlab.Labels{}, issue.Labels...), movedLabelName)
+			return m.migrateIssue(&moved, giteaMilestones, giteaLabels, giteaIssues)
+		})
+		if err != nil {
+			m.sendEvent(eventIssue, int64(issue.IID), 0, eventFailed)
+		}
+		return err
+	})
+}
+
+// ensureLabel creates the label in Gitea if it does not exist yet and adds it
+// to the given labels.
+func (m *migrator) ensureLabel(giteaLabels map[string]*gitea.Label, o gitea.CreateLabelOption) error {
+	if _, ok := giteaLabels[o.Name]; ok {
+		return nil
+	}
+
+	label, err := m.createLabel(o)
+	if err != nil {
+		return err
+	}
+	giteaLabels[label.Name] = label
+	m.logger.Info("Created label", log.String("name", o.Name), log.String("color", o.Color))
+	return nil
+}
+
+// movedIssueReference returns a reference to the issue that the given issue
+// was moved to. Looking up an issue by its global ID requires GitLab admin
+// access, without it only the ID is returned.
+func (m *migrator) movedIssueReference(issue *gitlab.Issue) string {
+	dest, _, err := m.gitlab.Issues.GetIssueByID(issue.MovedToID)
+	if err != nil || dest.References == nil {
+		m.logger.Debug("Getting moved issue destination failed",
+			log.Int("iid", issue.IID),
+			log.Int("moved_to_id", issue.MovedToID),
+			log.Err(err),
+		)
+		return fmt.Sprintf("the issue with ID %d", issue.MovedToID)
+	}
+	return fmt.Sprintf("[%s](%s)", dest.References.Full, dest.WebURL)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitlabIssueNotes returns all notes of the GitLab issue with the given IID
+// in ascending creation order, including system notes.
+func (m *migrator) gitlabIssueNotes(iid int) ([]*gitlab.Note, error) {
+	orderBy := "created_at"
+	sort := "asc"
+	var notes []*gitlab.Note
+
+	for page := 1; ; page++ {
+		opt := &gitlab.ListIssueNotesOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    page,
+				PerPage: gitlabPageSize,
+			},
+			OrderBy: &orderBy,
+			Sort:    &sort,
+		}
+
+		gitlabNotes, _, err := m.gitlab.Notes.ListIssueNotes(m.gitlabProjectID, iid, opt, nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing notes of issue %d: %w", iid, err)
+		}
+		logPage(m, "gitlab issue notes", page, gitlabNotes, func(note *gitlab.Note) int64 {
+			return int64(note.ID)
+		})
+		if len(gitlabNotes) == 0 {
+			return notes, nil
+		}
+		notes = append(notes, gitlabNotes...)
+	}
+}
+
+// commentsMarker starts the section of GitLab comments that is appended to
+// the issue body with --commentsinbody.
+const commentsMarker = "<!-- gitlab2gitea:comments -->"
+
+// commentsSection returns the non system notes of the GitLab issue formatted
+// as comments section of the issue body, prefixed with their author and date.
+// An empty string is returned for issues without comments.
+func (m *migrator) commentsSection(issue *gitlab.Issue) (string, error) {
+	if issue.UserNotesCount == 0 {
+		return "", nil
+	}
+	notes, err := m.gitlabIssueNotes(issue.IID)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "\n\n%s\n## Comments\n", commentsMarker)
+		}
+
+		var date string
+		if note.CreatedAt != nil {
+			date = " on " + note.CreatedAt.UTC().Format(time.DateTime)
+		}
+		fmt.Fprintf(&sb, "\n**%s**%s:\n\n%s\n", note.Author.Username, date, note.Body)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// packageNotesTitle is the title of the Gitea issue that lists the packages
+// and container images of the GitLab project.
+const packageNotesTitle = "GitLab packages and container images"
+
+// migratePackageNotes writes the packages and container images of the GitLab
+// project into a Gitea issue that serves as checklist for republishing them,
+// as the registries themselves are not migrated.
+func (m *migrator) migratePackageNotes() error {
+	packages, err := m.gitlabPackages()
+	if err != nil {
+		return err
+	}
+	images, err := m.gitlabContainerImages()
+	if err != nil {
+		return err
+	}
+	if len(packages) == 0 && len(images) == 0 {
+		m.logger.Info("No packages or container images found")
+		return nil
+	}
+
+	body := packageNotesBody(packages, images)
+	issues, err := m.giteaIssues()
+	if err != nil {
+		return err
+	}
+
+	existing, ok := issues[packageNotesTitle]
+	if !ok {
+		o := gitea.CreateIssueOption{
+			Title: packageNotesTitle,
+			Body:  body,
+		}
+		if _, _, err := m.gitea.CreateIssue(m.giteaOwner, m.giteaRepo, o); err != nil {
+			return err
+		}
+		m.logger.Info("Created package notes issue", log.Int("packages", len(packages)), log.Int("images", len(images)))
+		return nil
+	}
+
+	// keep the items that were already ticked in the checklist
+	body = mergeCheckedItems(body, existing.Body)
+	if body == existing.Body {
+		return nil
+	}
+
+	o := gitea.EditIssueOption{
+		Body: &body,
+	}
+	if _, _, err := m.gitea.EditIssue(m.giteaOwner, m.giteaRepo, existing.Index, o); err != nil {
+		return err
+	}
+	m.logger.Info("Updated package notes issue", log.Int("packages", len(packages)), log.Int("images", len(images)))
+	return nil
+}
+
+// gitlabPackages returns all packages of the GitLab project. If the package
+// registry is disabled, no packages are returned.
+func (m *migrator) gitlabPackages() ([]*gitlab.Package, error) {
+	var packages []*gitlab.Package
+	for page := 1; ; page++ {
+		opt := &gitlab.ListProjectPackagesOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    page,
+				PerPage: gitlabPageSize,
+			},
+		}
+
+		gitlabPackages, _, err := m.gitlab.Packages.ListProjectPackages(m.gitlabProjectID, opt, nil)
+		if err != nil {
+			if apiUnavailable(err) {
+				m.logger.Info("Package registry is not available", log.Err(err))
+				return nil, nil
+			}
+			return nil, fmt.Errorf("listing packages: %w", err)
+		}
+		logPage(m, "gitlab packages", page, gitlabPackages, func(pkg *gitlab.Package) int64 {
+			return int64(pkg.ID)
+		})
+		if len(gitlabPackages) == 0 {
+			return packages, nil
+		}
+		packages = append(packages, gitlabPackages...)
+	}
+}
+
+// gitlabContainerImages returns all container registry repositories of the
+// GitLab project. If the container registry is disabled, no repositories are
+// returned.
+func (m *migrator) gitlabContainerImages() ([]*gitlab.RegistryRepository, error) {
+	var images []*gitlab.RegistryRepository
+	tagsCount := true
+	for page := 1; ; page++ {
+		opt := &gitlab.ListRegistryRepositoriesOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    page,
+				PerPage: gitlabPageSize,
+			},
+			TagsCount: &tagsCount,
+		}
+
+		repos, _, err := m.gitlab.ContainerRegistry.ListProjectRegistryRepositories(m.gitlabProjectID, opt, nil)
+		if err != nil {
+			if apiUnavailable(err) {
+				m.logger.Info("Container registry is not available", log.Err(err))
+				return nil, nil
+			}
+			return nil, fmt.Errorf("listing container images: %w", err)
+		}
+		logPage(m, "gitlab container images", page, repos, func(repo *gitlab.RegistryRepository) int64 {
+			return int64(repo.ID)
+		})
+		if len(repos) == 0 {
+			return images, nil
+		}
+		images = append(images, repos...)
+	}
+}
+
+// apiUnavailable returns whether the error indicates that a GitLab API like
+// the package registry is disabled or not accessible for the project.
+func apiUnavailable(err error) bool {
+	if errors.Is(err, gitlab.ErrNotFound) {
+		return true
+	}
+
+	var errResp *gitlab.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		return errResp.Response.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// packageNotesBody returns the issue body listing the packages and container
+// images as checklist.
+func packageNotesBody(packages []*gitlab.Package, images []*gitlab.RegistryRepository) string {
+	var sb strings.Builder
+	sb.WriteString("The package and container registries of the GitLab project were not migrated. ")
+	sb.WriteString("The following items need to be republished manually.\n")
+
+	if len(packages) > 0 {
+		sb.WriteString("\n## Packages\n\n")
+		for _, pkg := range packages {
+			fmt.Fprintf(&sb, "- [ ] %s %s (%s)\n", pkg.Name, pkg.Version, pkg.PackageType)
+		}
+	}
+
+	if len(images) > 0 {
+		sb.WriteString("\n## Container images\n\n")
+		for _, image := range images {
+			fmt.Fprintf(&sb, "- [ ] %s (%d tags)\n", image.Location, image.TagsCount)
+		}
+	}
+
+	return sb.String()
+}
+
+// mergeCheckedItems returns the checklist body with the items checked that
+// are checked in the existing body. Items are matched by their text before
+// the details in parentheses, which can change between runs.
+func mergeCheckedItems(body, existing string) string {
+	checked := map[string]struct{}{}
+	for _, line := range strings.Split(existing, "\n") {
+		item, ok := strings.CutPrefix(line, "- [x] ")
+		if !ok {
+			item, ok = strings.CutPrefix(line, "- [X] ")
+		}
+		if ok {
+			checked[checklistItemKey(item)] = struct{}{}
+		}
+	}
+
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		item, ok := strings.CutPrefix(line, "- [ ] ")
+		if !ok {
+			continue
+		}
+		if _, ok := checked[checklistItemKey(item)]; ok {
+			lines[i] = "- [x] " + item
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checklistItemKey returns the text of a checklist item without the details
+// in parentheses.
+func checklistItemKey(item string) string {
+	key, _, _ := strings.Cut(item, " (")
+	return key
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestMergeCheckedItems(t *testing.T) {
+	packages := []*gitlab.Package{
+		{Name: "lib", Version: "1.0.0", PackageType: "npm"},
+		{Name: "lib", Version: "1.1.0", PackageType: "npm"},
+	}
+	images := []*gitlab.RegistryRepository{
+		{Location: "registry.example.com/group/project/app", TagsCount: 5},
+	}
+	existing := "## Packages\n\n- [x] lib 1.0.0 (npm)\n\n" +
+		"## Container images\n\n- [X] registry.example.com/group/project/app (3 tags)\n"
+
+	merged := mergeCheckedItems(packageNotesBody(packages, images), existing)
+	assert.Contains(t, merged, "- [x] lib 1.0.0 (npm)\n")
+	assert.Contains(t, merged, "- [ ] lib 1.1.0 (npm)\n")
+	assert.Contains(t, merged, "- [x] registry.example.com/group/project/app (5 tags)\n")
+}
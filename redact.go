@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// authHeaderRegex matches the values of authorization headers and token query
+// parameters like "Authorization: token abc" or "private_token=abc" in logged
+// strings, also JSON encoded.
+var authHeaderRegex = regexp.MustCompile(`(?i)((?:authorization|private[-_]token)[\\"']*\s*[:=]\s*[\\"']*)(?:(?:token|bearer|basic)\s+)?[^\s\\"',&]+`)
+
+// redactor removes the API tokens and authorization header values from the
+// log output and prompts, so that they can not leak through error messages
+// of the clients. It is always enabled.
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(secrets ...string) redactor {
+	var r redactor
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// redact returns the string with all secrets replaced.
+func (r redactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return authHeaderRegex.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// writer returns a writer that redacts everything that is written to it
+// before passing it on to w. Log handlers write every record with a single
+// call, so secrets are not split across writes.
+func (r redactor) writer(w io.Writer) io.Writer {
+	return redactingWriter{redactor: r, w: w}
+}
+
+type redactingWriter struct {
+	redactor
+	w io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cornelk/gotokit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactLogger(t *testing.T) {
+	const (
+		gitlabToken = "glpat-abcdef123456"
+		giteaToken  = "0123456789abcdef0123456789abcdef"
+		otherToken  = "unknown-secret-42"
+	)
+
+	var buf bytes.Buffer
+	logger, err := createLogger(&buf, newRedactor(gitlabToken, giteaToken))
+	require.NoError(t, err)
+
+	err = errors.New("request failed: token " + gitlabToken + " and " + giteaToken +
+		", headers Authorization: token " + otherToken +
+		" PRIVATE-TOKEN: " + otherToken +
+		", URL https://gitlab.com/api/v4/projects?private_token=" + otherToken + "&page=2")
+	logger.Error("Migrating issue failed", log.Err(err))
+
+	output := buf.String()
+	for _, secret := range []string{gitlabToken, giteaToken, otherToken} {
+		assert.NotContains(t, output, secret)
+	}
+	assert.Contains(t, output, "token REDACTED and REDACTED")
+	assert.Contains(t, output, "Authorization: REDACTED")
+	assert.Contains(t, output, "PRIVATE-TOKEN: REDACTED")
+	assert.Contains(t, output, "private_token=REDACTED&page=2")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+)
+
+// refsRewrittenMarker is added to issue bodies whose references have been
+// rewritten, so that rerunning the phase does not rewrite them twice.
+const refsRewrittenMarker = "<!-- gitlab2gitea:refs-rewritten -->"
+
+var issueReferenceRegex = regexp.MustCompile(`(^|[\s(\[])#(\d+)\b`)
+
+// rewriteReferences rewrites the GitLab issue references like #12 in the
+// bodies of all migrated Gitea issues to the index of the Gitea issue that
+// the referenced GitLab issue was migrated to. The issues are processed
+// concurrently by the configured number of workers.
+func (m *migrator) rewriteReferences() error {
+	giteaIssues, err := m.listGiteaIssues()
+	if err != nil {
+		return err
+	}
+	indices := m.issueIndices(giteaIssues)
+
+	work := make(chan int64)
+	errs := make(chan error, m.args.Concurrency)
+	var wg sync.WaitGroup
+
+	for range m.args.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range work {
+				if err := m.rewriteIssueReferences(index, indices); err != nil {
+					errs <- fmt.Errorf("rewriting references of issue %d: %w", index, err)
+					return
+				}
+			}
+		}()
+	}
+
+	var workerErr error
+queue:
+	for _, issue := range giteaIssues {
+		if m.markerRef(issue.Body) == "" || strings.Contains(issue.Body, refsRewrittenMarker) {
+			continue
+		}
+
+		select {
+		case work <- issue.Index:
+		case workerErr = <-errs:
+			break queue
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if workerErr != nil {
+		return workerErr
+	}
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// issueIndices returns a map of GitLab issue IIDs of the migrated project to
+// the index of the Gitea issue that they were migrated to.
+func (m *migrator) issueIndices(giteaIssues []*gitea.Issue) map[int]int64 {
+	prefix := m.args.GitlabProject + "#"
+	indices := make(map[int]int64, len(giteaIssues))
+
+	for _, issue := range giteaIssues {
+		ref := m.markerRef(issue.Body)
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+
+		iid, err := strconv.Atoi(strings.TrimPrefix(ref, prefix))
+		if err != nil {
+			continue
+		}
+		indices[iid] = issue.Index
+	}
+	return indices
+}
+
+// rewriteIssueReferences rewrites the references in the body of the Gitea
+// issue with the given index.
+func (m *migrator) rewriteIssueReferences(index int64, indices map[int]int64) error {
+	issue, _, err := m.gitea.GetIssue(m.giteaOwner, m.giteaRepo, index)
+	if err != nil {
+		return fmt.Errorf("getting issue: %w", err)
+	}
+	if strings.Contains(issue.Body, refsRewrittenMarker) {
+		return nil
+	}
+
+	body := rewriteIssueReferences(issue.Body, indices)
+	if body == issue.Body {
+		return nil
+	}
+	body += "\n" + refsRewrittenMarker
+
+	o := gitea.EditIssueOption{
+		Title: issue.Title,
+		Body:  &body,
+	}
+	if _, _, err := m.gitea.EditIssue(m.giteaOwner, m.giteaRepo, index, o); err != nil {
+		return fmt.Errorf("editing issue: %w", err)
+	}
+
+	m.logger.Info("Rewrote issue references", log.Int64("index", index))
+	return nil
+}
+
+// rewriteIssueReferences replaces all issue references like #12 in the text
+// with the reference to the Gitea issue index from the indices map. Unknown
+// references and references inside code are left untouched.
+func rewriteIssueReferences(text string, indices map[int]int64) string {
+	return replaceOutsideCode(text, func(s string) string {
+		return issueReferenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+			match := issueReferenceRegex.FindStringSubmatch(ref)
+			iid, err := strconv.Atoi(match[2])
+			if err != nil {
+				return ref
+			}
+			index, ok := indices[iid]
+			if !ok {
+				return ref
+			}
+			return fmt.Sprintf("%s#%d", match[1], index)
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteIssueReferences(t *testing.T) {
+	indices := map[int]int64{12: 3, 5: 9}
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain", "see #12", "see #3"},
+		{"line start", "#12 is related", "#3 is related"},
+		{"parentheses", "fixed (#12)", "fixed (#3)"},
+		{"brackets", "[#5]", "[#9]"},
+		{"unknown IID", "see #7", "see #7"},
+		{"no reference", "issue#12 and #12a", "issue#12 and #12a"},
+		{"fenced code", "```\n#12\n```\n#12", "```\n#12\n```\n#3"},
+		{"inline code", "run `git log #12` for #12", "run `git log #12` for #3"},
+		{"marker", "text\n\n<!-- gitlab2gitea:group/project#12 -->", "text\n\n<!-- gitlab2gitea:group/project#12 -->"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rewriteIssueReferences(tt.text, indices))
+		})
+	}
+}
+
+func TestRewriteIssueReferencesIdempotent(t *testing.T) {
+	issue := &gitea.Issue{Index: 1, Title: "Title", Body: "see #12"}
+	var edits int
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/repos/owner/repo/issues/1": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPatch {
+				var o gitea.EditIssueOption
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&o))
+				issue.Body = *o.Body
+				edits++
+			}
+			writeJSON(t, w, issue)
+		},
+	})
+
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+	m := newTestMigrator(t, arguments{})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+
+	indices := map[int]int64{12: 3}
+	require.NoError(t, m.rewriteIssueReferences(1, indices))
+	assert.Equal(t, "see #3\n"+refsRewrittenMarker, issue.Body)
+
+	// the marker prevents rewriting #3 again on the next run
+	indices[3] = 8
+	require.NoError(t, m.rewriteIssueReferences(1, indices))
+	assert.Equal(t, 1, edits)
+	assert.Equal(t, "see #3\n"+refsRewrittenMarker, issue.Body)
+}
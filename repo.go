@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+)
+
+// setDefaultBranch sets the default branch of the Gitea repo to the configured
+// branch or the default branch of the GitLab project. A configured branch has
+// to exist in the Gitea repo, the GitLab default branch is skipped if missing.
+func (m *migrator) setDefaultBranch() error {
+	branch := m.args.GiteaDefaultBranch
+	if m.args.CopyDefaultBranch {
+		branch = m.gitlabDefaultBranch
+		if branch == "" {
+			m.logger.Warn("GitLab project has no default branch")
+			return nil
+		}
+	}
+
+	_, resp, err := m.gitea.GetRepoBranch(m.giteaOwner, m.giteaRepo, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			if !m.args.CopyDefaultBranch {
+				return fmt.Errorf("branch '%s' does not exist in Gitea", branch)
+			}
+			m.logger.Warn("Not setting default branch that does not exist in Gitea", log.String("branch", branch))
+			return nil
+		}
+		return fmt.Errorf("getting branch '%s': %w", branch, err)
+	}
+
+	repo, _, err := m.gitea.GetRepo(m.giteaOwner, m.giteaRepo)
+	if err != nil {
+		return fmt.Errorf("getting repo: %w", err)
+	}
+	if repo.DefaultBranch == branch {
+		return nil
+	}
+
+	o := gitea.EditRepoOption{
+		DefaultBranch: &branch,
+	}
+	if _, _, err := m.gitea.EditRepo(m.giteaOwner, m.giteaRepo, o); err != nil {
+		return fmt.Errorf("editing repo: %w", err)
+	}
+	m.logger.Info("Set default branch", log.String("branch", branch))
+	return nil
+}
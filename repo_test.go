@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   arguments
+		err    string
+		edited bool
+	}{
+		{"explicit branch", arguments{GiteaDefaultBranch: "main"}, "", true},
+		{"missing explicit branch", arguments{GiteaDefaultBranch: "trunk"}, "branch 'trunk' does not exist", false},
+		{"GitLab default branch", arguments{CopyDefaultBranch: true}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var edited bool
+			server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+				"/api/v1/repos/owner/repo/branches/main": func(w http.ResponseWriter, _ *http.Request) {
+					writeJSON(t, w, gitea.Branch{Name: "main"})
+				},
+				"/api/v1/repos/owner/repo/branches/": func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusNotFound)
+					writeJSON(t, w, map[string]string{"message": "branch not found"})
+				},
+				"/api/v1/repos/owner/repo": func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == http.MethodPatch {
+						edited = true
+					}
+					writeJSON(t, w, gitea.Repository{DefaultBranch: "master"})
+				},
+			})
+			client, err := gitea.NewClient(server.URL)
+			require.NoError(t, err)
+
+			m := newTestMigrator(t, tt.args)
+			m.gitea = client
+			m.giteaOwner = "owner"
+			m.giteaRepo = "repo"
+			m.gitlabDefaultBranch = "main"
+
+			err = m.setDefaultBranch()
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+			assert.Equal(t, tt.edited, edited)
+		})
+	}
+}
+
+func TestSetDefaultBranchMissingGitlabBranch(t *testing.T) {
+	server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+		"/api/v1/repos/owner/repo/branches/": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, map[string]string{"message": "branch not found"})
+		},
+	})
+	client, err := gitea.NewClient(server.URL)
+	require.NoError(t, err)
+
+	m := newTestMigrator(t, arguments{CopyDefaultBranch: true})
+	m.gitea = client
+	m.giteaOwner = "owner"
+	m.giteaRepo = "repo"
+	m.gitlabDefaultBranch = "develop"
+
+	assert.NoError(t, m.setDefaultBranch())
+}
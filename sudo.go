@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// sudoAuthor returns the Gitea user to create the issue as, which is the user
+// with the username of the GitLab author. If the author has no Gitea user, an
+// empty name is returned and a footer naming the GitLab author is added to
+// the issue body instead.
+func (m *migrator) sudoAuthor(issue *gitlab.Issue, o *gitea.CreateIssueOption) (string, error) {
+	if !m.args.SudoAuthors || issue.Author == nil {
+		return "", nil
+	}
+
+	author := issue.Author.Username
+	exists, err := m.giteaUserExists(author)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return author, nil
+	}
+
+	m.logger.Debug("Author has no Gitea user", log.String("author", author))
+	o.Body += fmt.Sprintf("\n\n_Created in GitLab by @%s._", author)
+	return "", nil
+}
+
+// createIssue creates a Gitea issue, as the given sudo user if it is set. The
+// fields that need write access to the repo are set afterwards as admin, as
+// the author might not have it. If that fails, the created issue is returned
+// with the error.
+func (m *migrator) createIssue(o gitea.CreateIssueOption, sudo string) (*gitea.Issue, error) {
+	if sudo == "" {
+		created, _, err := m.gitea.CreateIssue(m.giteaOwner, m.giteaRepo, o)
+		return created, err
+	}
+
+	authored := gitea.CreateIssueOption{
+		Title: o.Title,
+		Body:  o.Body,
+	}
+	m.gitea.SetSudo(sudo)
+	created, _, err := m.gitea.CreateIssue(m.giteaOwner, m.giteaRepo, authored)
+	m.gitea.SetSudo("")
+	if err != nil {
+		return nil, fmt.Errorf("creating issue as %s: %w", sudo, err)
+	}
+
+	// the created issue is returned also on error, to not create it again
+	if err := m.updateIssue(created, o); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+// giteaUserExists returns whether a Gitea user with the given name exists.
+// The results are cached for the run.
+func (m *migrator) giteaUserExists(name string) (bool, error) {
+	if exists, ok := m.giteaUsers[name]; ok {
+		return exists, nil
+	}
+
+	_, resp, err := m.gitea.GetUserInfo(name)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return false, fmt.Errorf("getting Gitea user %s: %w", name, err)
+	}
+
+	exists := err == nil
+	m.giteaUsers[name] = exists
+	return exists, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Supported modes of migrating GitLab spent time.
+const (
+	timeModeNone     = "none"
+	timeModeDetailed = "detailed"
+)
+
+// spentTimeMarker identifies the spent time summary comment that is created
+// if time tracking is disabled in Gitea.
+const spentTimeMarker = "<!-- gitlab2gitea:spent-time -->"
+
+var (
+	// spentTimeNoteRegex matches GitLab system notes like
+	// "added 1h 30m of time spent at 2021-05-01".
+	spentTimeNoteRegex = regexp.MustCompile(`^(added|subtracted) (.+?) of time spent(?: at (\d{4}-\d{2}-\d{2}))?`)
+
+	gitlabDurationRegex = regexp.MustCompile(`(\d+)(mo|w|d|h|m|s)`)
+)
+
+// gitlabDurationUnits contains the durations of the GitLab time units, using
+// the GitLab defaults of 8 hours per day, 5 days per week and 4 weeks per month.
+var gitlabDurationUnits = map[string]time.Duration{
+	"mo": 4 * 5 * 8 * time.Hour,
+	"w":  5 * 8 * time.Hour,
+	"d":  8 * time.Hour,
+	"h":  time.Hour,
+	"m":  time.Minute,
+	"s":  time.Second,
+}
+
+// spentTime is a single spent time entry of a GitLab issue.
+type spentTime struct {
+	user     string
+	created  time.Time
+	duration time.Duration
+}
+
+// detectTimeTracking checks whether time tracking is enabled for the Gitea repo.
+func (m *migrator) detectTimeTracking() error {
+	repo, _, err := m.gitea.GetRepo(m.giteaOwner, m.giteaRepo)
+	if err != nil {
+		return fmt.Errorf("getting Gitea repo info: %w", err)
+	}
+
+	m.timeTracking = repo.InternalTracker != nil && repo.InternalTracker.EnableTimeTracker
+	if !m.timeTracking {
+		m.logger.Warn("Time tracking is disabled in Gitea, spent time is added as summary comment")
+	}
+	return nil
+}
+
+// migrateSpentTime replays every spent time entry of the GitLab issue as
+// tracked time of the same user in Gitea. If time tracking is disabled in
+// Gitea, a summary comment is created instead. Entries that already exist
+// in Gitea are skipped.
+func (m *migrator) migrateSpentTime(issue *gitlab.Issue, index int64) error {
+	notes, err := m.gitlabIssueNotes(issue.IID)
+	if err != nil {
+		return err
+	}
+
+	entries := m.spentTimeEntries(notes)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if !m.timeTracking {
+		return m.createSpentTimeComment(index, entries)
+	}
+
+	existing, err := m.giteaTrackedTimes(index)
+	if err != nil {
+		return err
+	}
+
+	var unknown []spentTime
+	for _, entry := range entries {
+		if trackedTimeExists(existing, entry) {
+			continue
+		}
+		replay, err := m.canTrackTimeFor(entry.user)
+		if err != nil {
+			return err
+		}
+		if !replay {
+			unknown = append(unknown, entry)
+			continue
+		}
+
+		o := gitea.AddTimeOption{
+			Time:    int64(entry.duration.Seconds()),
+			Created: entry.created,
+			User:    entry.user,
+		}
+		if _, _, err := m.gitea.AddTime(m.giteaOwner, m.giteaRepo, index, o); err != nil {
+			return fmt.Errorf("adding tracked time of user '%s': %w", entry.user, err)
+		}
+		m.logger.Debug("Added tracked time",
+			log.Int64("index", index),
+			log.String("user", entry.user),
+			log.Duration("duration", entry.duration),
+		)
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	m.logger.Info("Listing spent time of users without Gitea user in a comment",
+		log.Int64("index", index),
+		log.Int("entries", len(unknown)),
+	)
+	return m.createSpentTimeComment(index, unknown)
+}
+
+// canTrackTimeFor returns whether tracked time can be added for the Gitea user
+// with the given name. This needs the user to exist and, for other users than
+// the one of the token, a Gitea admin token.
+func (m *migrator) canTrackTimeFor(user string) (bool, error) {
+	if m.giteaUser != nil && user == m.giteaUser.UserName {
+		return true, nil
+	}
+	if m.giteaUser == nil || !m.giteaUser.IsAdmin {
+		return false, nil
+	}
+	return m.giteaUserExists(user)
+}
+
+// spentTimeEntries returns the spent time entries of the system notes.
+func (m *migrator) spentTimeEntries(notes []*gitlab.Note) []spentTime {
+	var entries []spentTime
+	for _, note := range notes {
+		if !note.System {
+			continue
+		}
+
+		match := spentTimeNoteRegex.FindStringSubmatch(note.Body)
+		if match == nil {
+			continue
+		}
+
+		duration := parseGitlabDuration(match[2])
+		if duration == 0 {
+			m.logger.Warn("Unsupported spent time note", log.String("note", note.Body))
+			continue
+		}
+		if match[1] == "subtracted" {
+			duration = -duration
+		}
+
+		entry := spentTime{
+			user:     note.Author.Username,
+			duration: duration,
+		}
+		if note.CreatedAt != nil {
+			// Gitea stores the creation time with second precision
+			entry.created = note.CreatedAt.Truncate(time.Second)
+		}
+		if match[3] != "" {
+			if date, err := time.Parse(time.DateOnly, match[3]); err == nil {
+				entry.created = date
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// giteaTrackedTimes returns all tracked times of the Gitea issue.
+func (m *migrator) giteaTrackedTimes(index int64) ([]*gitea.TrackedTime, error) {
+	var times []*gitea.TrackedTime
+	for page := 1; ; page++ {
+		opt := gitea.ListTrackedTimesOptions{
+			ListOptions: gitea.ListOptions{
+				Page: page,
+			},
+		}
+		trackedTimes, _, err := m.gitea.ListIssueTrackedTimes(m.giteaOwner, m.giteaRepo, index, opt)
+		if err != nil {
+			return nil, fmt.Errorf("listing tracked times: %w", err)
+		}
+		if len(trackedTimes) == 0 {
+			return times, nil
+		}
+		times = append(times, trackedTimes...)
+	}
+}
+
+// createSpentTimeComment creates a comment that lists all spent time entries,
+// unless the issue already has one.
+func (m *migrator) createSpentTimeComment(index int64, entries []spentTime) error {
+	comments, err := m.giteaIssueComments(index)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, spentTimeMarker) {
+			return nil
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Time spent in GitLab:\n\n")
+	var total time.Duration
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "- %s: @%s %s\n", entry.created.Format(time.DateOnly), entry.user, entry.duration)
+		total += entry.duration
+	}
+	fmt.Fprintf(&sb, "\nTotal: %s\n\n%s", total, spentTimeMarker)
+
+	o := gitea.CreateIssueCommentOption{
+		Body: sb.String(),
+	}
+	if _, _, err := m.gitea.CreateIssueComment(m.giteaOwner, m.giteaRepo, index, o); err != nil {
+		return fmt.Errorf("creating spent time comment: %w", err)
+	}
+	return nil
+}
+
+// trackedTimeExists returns whether the spent time entry already exists in
+// the tracked times.
+func trackedTimeExists(existing []*gitea.TrackedTime, entry spentTime) bool {
+	for _, tracked := range existing {
+		if tracked.UserName == entry.user &&
+			tracked.Time == int64(entry.duration.Seconds()) &&
+			tracked.Created.Equal(entry.created) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGitlabDuration parses a GitLab duration like 1d 2h 30m. It returns 0
+// for durations that can not be parsed.
+func parseGitlabDuration(s string) time.Duration {
+	var duration time.Duration
+	for _, match := range gitlabDurationRegex.FindAllStringSubmatch(s, -1) {
+		value, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0
+		}
+		duration += time.Duration(value) * gitlabDurationUnits[match[2]]
+	}
+	return duration
+}
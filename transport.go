@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// timeoutRetries is the number of retries of a timed out API request.
+	timeoutRetries = 3
+	// timeoutRetryBackoff is the wait before the first retry of a timed out
+	// API request, it doubles with every further retry.
+	timeoutRetryBackoff = 2 * time.Second
+)
+
+// htmlResponseCheck fails API requests that return an HTML page instead of
+// JSON. Proxies in front of GitLab or Gitea can answer authentication or
+// permission failures with a login page and status 200, which would otherwise
+// fail later with a confusing JSON decoding error. Error responses are passed
+// on, to keep the status based handling and retries of the clients.
+type htmlResponseCheck struct {
+	next http.RoundTripper
+}
+
+type allowHTMLKey struct{}
+
+// allowHTML returns a context for requests of HTML pages that are not checked
+// by the HTML response check.
+func allowHTML(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowHTMLKey{}, true)
+}
+
+func (c htmlResponseCheck) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || req.Context().Value(allowHTMLKey{}) != nil {
+		return resp, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" {
+		return resp, nil
+	}
+
+	_ = resp.Body.Close()
+	return nil, fmt.Errorf("received HTML instead of JSON with status %d from %s %s, "+
+		"this is likely an authentication or proxy redirect", resp.StatusCode, req.Method, req.URL.Redacted())
+}
+
+// timeoutRetry applies the request timeout to every single attempt of an API
+// request and retries idempotent requests that timed out with an increasing
+// backoff. Requests that create items are not retried, as the server may have
+// processed them already.
+type timeoutRetry struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func (t timeoutRetry) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req, attempt)
+		if err == nil || !t.retryable(req, err, attempt) {
+			return resp, err
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// roundTrip sends a single attempt of the request with its own timeout, the
+// body of a repeated request is read again from the original request.
+func (t timeoutRetry) roundTrip(req *http.Request, attempt int) (*http.Response, error) {
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	attemptReq := req.WithContext(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("reading request body for retry: %w", err)
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.next.RoundTrip(attemptReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout also covers reading the response body
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable returns whether the failed attempt of the request can be retried.
+func (t timeoutRetry) retryable(req *http.Request, err error, attempt int) bool {
+	if attempt >= t.retries || req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// cancelOnClose cancels the context of a request when its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSlowServer returns a server that answers the first slow requests only
+// after the client timed out.
+func newSlowServer(t *testing.T, slow int64, requests *atomic.Int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if requests.Add(1) <= slow {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTimeoutRetryClient(server *httptest.Server) *http.Client {
+	return &http.Client{
+		Transport: timeoutRetry{
+			next:    server.Client().Transport,
+			timeout: 50 * time.Millisecond,
+			retries: 2,
+			backoff: time.Millisecond,
+		},
+	}
+}
+
+func TestTimeoutRetry(t *testing.T) {
+	var requests atomic.Int64
+	server := newSlowServer(t, 2, &requests)
+	client := newTimeoutRetryClient(server)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"title":"a"}`))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"a"}`, string(body))
+	assert.Equal(t, int64(3), requests.Load())
+}
+
+func TestTimeoutRetryExhausted(t *testing.T) {
+	var requests atomic.Int64
+	server := newSlowServer(t, 3, &requests)
+	client := newTimeoutRetryClient(server)
+
+	resp, err := client.Get(server.URL)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	require.ErrorContains(t, err, "deadline exceeded")
+	assert.Equal(t, int64(3), requests.Load())
+}
+
+func TestTimeoutRetrySkipsCreate(t *testing.T) {
+	var requests atomic.Int64
+	server := newSlowServer(t, 1, &requests)
+	client := newTimeoutRetryClient(server)
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"title":"a"}`))
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	require.Error(t, err)
+	assert.Equal(t, int64(1), requests.Load())
+}
+
+func TestHTMLResponseCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body>Sign in</body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{
+		Transport: htmlResponseCheck{next: server.Client().Transport},
+	}
+	resp, err := client.Get(server.URL + "/api/v4/projects/1")
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "received HTML instead of JSON with status 200")
+	assert.Contains(t, err.Error(), "GET "+server.URL+"/api/v4/projects/1")
+}
+
+func TestHTMLResponseCheckPassesErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html><body>Bad Gateway</body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{
+		Transport: htmlResponseCheck{next: server.Client().Transport},
+	}
+	resp, err := client.Get(server.URL + "/api/v4/projects/1")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// createIssueSkeletons is the first pass of the two pass issue migration. It
+// creates every missing issue with only its title and marker, to establish
+// the Gitea index of all issues before the second pass fills in the content.
+// The resulting map of GitLab IIDs to Gitea indices is stored for the second
+// pass. Skeletons of an interrupted run are found again by their marker.
+func (m *migrator) createIssueSkeletons(giteaIssues map[string]*gitea.Issue) error {
+	m.passOneIndices = map[int]int64{}
+
+	return m.forEachGitlabIssue("opened", func(issue *gitlab.Issue) error {
+		if existing, ok := giteaIssues[m.gitlabIssueKey(issue)]; ok {
+			m.passOneIndices[issue.IID] = existing.Index
+			return nil
+		}
+
+		return m.migrateItem(fmt.Sprintf("Issue %d", issue.IID), func() error {
+			o := gitea.CreateIssueOption{
+				Title: m.issueTitle(issue),
+				Body:  m.issueMarker(issue),
+			}
+			created, _, err := m.gitea.CreateIssue(m.giteaOwner, m.giteaRepo, o)
+			if err != nil {
+				return err
+			}
+
+			m.passOneIndices[issue.IID] = created.Index
+			m.logger.Info("Created issue skeleton", log.String("title", o.Title), log.Int64("index", created.Index))
+			return nil
+		})
+	})
+}
+
+// rewritePassTwoBody rewrites the issue references in the body of the second
+// pass of the two pass issue migration, using the indices of the first pass.
+// Rewritten bodies are marked to be skipped by the reference rewriting phase.
+func (m *migrator) rewritePassTwoBody(body string) string {
+	if m.passOneIndices == nil {
+		return body
+	}
+
+	rewritten := rewriteIssueReferences(body, m.passOneIndices)
+	if rewritten == body {
+		return body
+	}
+	return rewritten + "\n" + refsRewrittenMarker
+}
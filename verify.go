@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cornelk/gotokit/log"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// anyMarkerRegex matches all hidden markers that are added by this tool.
+var anyMarkerRegex = regexp.MustCompile(`<!-- gitlab2gitea:\S+ -->`)
+
+// verifyIssueBody fetches the migrated issue from Gitea and compares the hash
+// of its body with the hash of the GitLab issue description, to detect
+// truncation or encoding issues. The sections that this tool adds to the body
+// are ignored, the description is compared as it was sent, with normalized
+// emoji and rewritten references. Mismatches are logged and reported after
+// the migration of all issues.
+func (m *migrator) verifyIssueBody(issue *gitlab.Issue, index int64) error {
+	migrated, _, err := m.gitea.GetIssue(m.giteaOwner, m.giteaRepo, index)
+	if err != nil {
+		return fmt.Errorf("getting issue %d for verification: %w", index, err)
+	}
+
+	description := m.normalizeEmoji(issue.Description)
+	if m.passOneIndices != nil {
+		description = rewriteIssueReferences(description, m.passOneIndices)
+	}
+	migratedDescription, ok := m.migratedDescription(migrated.Body)
+	if !ok {
+		// the marker ends the body, without it the body is likely truncated
+		m.logger.Warn("Migrated issue body has no marker",
+			log.Int("iid", issue.IID),
+			log.Int64("index", index),
+			log.Int("actual_length", len(migrated.Body)),
+		)
+		m.bodyMismatches = append(m.bodyMismatches, m.gitlabIssueRef(issue))
+		return nil
+	}
+
+	expected := bodyHash(description)
+	actual := bodyHash(migratedDescription)
+	if expected == actual {
+		return nil
+	}
+
+	m.logger.Warn("Migrated issue body does not match",
+		log.Int("iid", issue.IID),
+		log.Int64("index", index),
+		log.Int("expected_length", len(description)),
+		log.Int("actual_length", len(migratedDescription)),
+		log.String("expected_hash", expected),
+		log.String("actual_hash", actual),
+	)
+	m.bodyMismatches = append(m.bodyMismatches, m.gitlabIssueRef(issue))
+	return nil
+}
+
+// migratedDescription returns the part of a migrated issue body that contains
+// the GitLab issue description. The comments section, the issue marker and
+// everything that follows the marker are added by this tool and removed. If
+// the body contains no marker, false is returned.
+func (m *migrator) migratedDescription(body string) (string, bool) {
+	matches := m.markerRegex.FindAllStringIndex(body, -1)
+	if matches == nil {
+		return "", false
+	}
+	description := body[:matches[len(matches)-1][0]]
+	if i := strings.Index(description, commentsMarker); i >= 0 {
+		description = description[:i]
+	}
+	return description, true
+}
+
+// reportBodyMismatches logs the GitLab issues whose migrated bodies did not
+// match on verification.
+func (m *migrator) reportBodyMismatches() {
+	if !m.args.VerifyBodies {
+		return
+	}
+	if len(m.bodyMismatches) == 0 {
+		m.logger.Info("All verified issue bodies match")
+		return
+	}
+	m.logger.Warn("Issue bodies do not match",
+		log.Int("count", len(m.bodyMismatches)),
+		log.String("issues", strings.Join(m.bodyMismatches, ", ")),
+	)
+}
+
+// bodyHash returns the hash of an issue body without the markers of this tool
+// and normalized line endings and surrounding whitespace.
+func bodyHash(body string) string {
+	body = anyMarkerRegex.ReplaceAllString(body, "")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.TrimSpace(body)
+
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestVerifyIssueBody(t *testing.T) {
+	const marker = "<!-- gitlab2gitea:group/project#1 -->"
+	tests := []struct {
+		name     string
+		body     string
+		mismatch bool
+	}{
+		{"equal", "Fix the :bug:\r\n\n" + marker, false},
+		{"added sections", "Fix the :bug:\n\n" + commentsMarker + "\n## Comments\n\n**user**:\n\nfirst\n\n" + marker +
+			"\n" + refsRewrittenMarker + "\n\n_Created in GitLab by @user._", false},
+		{"truncated", "Fix the\n\n" + marker, true},
+		{"changed on server", "Fix the bug\n\n" + marker, true},
+		{"missing marker", "Fix the :bug:", true},
+		{"truncated in comments", "Fix the :bug:\n\n" + commentsMarker + "\n## Comments\n\n**user**:", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestGiteaServer(t, map[string]http.HandlerFunc{
+				"/api/v1/repos/owner/repo/issues/7": func(w http.ResponseWriter, _ *http.Request) {
+					writeJSON(t, w, gitea.Issue{Index: 7, Body: tt.body})
+				},
+			})
+			client, err := gitea.NewClient(server.URL)
+			require.NoError(t, err)
+
+			m := newTestMigrator(t, arguments{
+				GitlabProject: "group/project",
+				MarkerFormat:  "gitlab2gitea:{project}#{iid}",
+				MarkerStyle:   markerStyleHTML,
+			})
+			m.markerRegex, err = compileMarkerRegex(m.args.MarkerFormat, m.args.MarkerStyle)
+			require.NoError(t, err)
+			m.gitea = client
+			m.giteaOwner = "owner"
+			m.giteaRepo = "repo"
+
+			issue := &gitlab.Issue{IID: 1, Description: "Fix the :bug:"}
+			require.NoError(t, m.verifyIssueBody(issue, 7))
+			if tt.mismatch {
+				assert.Equal(t, []string{"group/project#1"}, m.bodyMismatches)
+			} else {
+				assert.Empty(t, m.bodyMismatches)
+			}
+		})
+	}
+}
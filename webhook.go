@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cornelk/gotokit/log"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// Types of migrated items that are sent as webhook events.
+const (
+	eventIssue     = "issue"
+	eventLabel     = "label"
+	eventMilestone = "milestone"
+)
+
+// Statuses of migrated items that are sent as webhook events.
+const (
+	eventCreated = "created"
+	eventUpdated = "updated"
+	eventFailed  = "failed"
+)
+
+// webhookEvent is posted to the webhook URL after every migrated item.
+type webhookEvent struct {
+	RunID    string `json:"run_id"`
+	Type     string `json:"type"`
+	SourceID int64  `json:"source_id"`
+	TargetID int64  `json:"target_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+// newRunID returns a random ID that identifies all webhook events of a run.
+func newRunID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("reading random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sendEvent posts an event for a migrated item to the webhook URL if one is
+// configured. The event is sent in the background so that a slow or failing
+// webhook never blocks the migration, failures are only logged at debug level.
+func (m *migrator) sendEvent(typ string, sourceID, targetID int64, status string) {
+	if m.args.Webhook == "" {
+		return
+	}
+
+	event := webhookEvent{
+		RunID:    m.runID,
+		Type:     typ,
+		SourceID: sourceID,
+		TargetID: targetID,
+		Status:   status,
+	}
+
+	m.webhooks.Add(1)
+	go func() {
+		defer m.webhooks.Done()
+		if err := m.postEvent(event); err != nil {
+			m.logger.Debug("Sending webhook event failed", log.Err(err))
+		}
+	}()
+}
+
+// postEvent posts the JSON encoded event to the webhook URL.
+func (m *migrator) postEvent(event webhookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.args.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}